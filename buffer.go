@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithBuffer buffers up to size bytes of output in memory, writing it out when
+// the buffer fills, every flushInterval, and on Sync. This trades the last
+// flushInterval of entries on a crash for far fewer write syscalls under load,
+// so call Sync or Shutdown before the program exits. A size or flushInterval
+// of 0 uses zap's defaults of 256 kB and 30 seconds.
+func WithBuffer(size int, flushInterval time.Duration) loggerOpt {
+	return func(b *builder) error {
+		if size < 0 || flushInterval < 0 {
+			return fmt.Errorf("%w: buffer size (%d) and flush interval (%s) must not be negative", ErrInvalidOption, size, flushInterval)
+		}
+		b.buffer = func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+			buffered := &zapcore.BufferedWriteSyncer{WS: ws, Size: size, FlushInterval: flushInterval}
+			b.onShutdown(buffered.Stop)
+			return buffered
+		}
+		return nil
+	}
+}
+
+// openOutputs opens paths as a single WriteSyncer, buffered if WithBuffer was
+// given.
+func (b *builder) openOutputs(paths []string) (zapcore.WriteSyncer, error) {
+	ws, closeOutputs, err := zap.Open(paths...)
+	if err != nil {
+		return nil, err
+	}
+	if b.buffer != nil {
+		ws = b.buffer(ws)
+	}
+	b.onShutdown(func() error {
+		closeOutputs()
+		return nil
+	})
+	return ws, nil
+}
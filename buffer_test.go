@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithBuffer(t *testing.T) {
+	log, buf := newBufferLogger(t, WithoutSampling(), WithBuffer(64*1024, time.Hour))
+	for i := 0; i < 100; i++ {
+		log.Infow("buffered", "i", i)
+	}
+	if out := buf.String(); out != "" {
+		t.Fatalf("got %d bytes before Sync, want them buffered", len(out))
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := len(decodeLines(t, buf)); got != 100 {
+		t.Errorf("got %d lines after Sync, want 100", got)
+	}
+
+	if _, err := New(TestService, WithBuffer(-1, 0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("negative size error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithBufferFlushedByShutdown(t *testing.T) {
+	log, buf := newBufferLogger(t, WithBuffer(0, 0))
+	log.Info("last words")
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["msg"] != "last words" {
+		t.Errorf("got %q, want the buffered line flushed", buf.String())
+	}
+}
+
+// countingWriter counts the writes made to it, standing in for syscalls.
+type countingWriter struct{ writes atomic.Int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes.Add(1)
+	return len(p), nil
+}
+
+func BenchmarkBuffer(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		opts []loggerOpt
+	}{
+		{"unbuffered", nil},
+		{"buffered", []loggerOpt{WithBuffer(0, 0)}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			w := &countingWriter{}
+			opts := append([]loggerOpt{WithOutputPaths(), WithOutputWriter(w), WithoutSampling()}, bb.opts...)
+			log, err := New(TestService, opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer Shutdown(log)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				log.Infow("request", "path", "/", "status", 200)
+			}
+			_ = log.Sync()
+			b.ReportMetric(float64(w.writes.Load())/float64(b.N), "writes/op")
+		})
+	}
+}
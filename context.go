@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type (
+	loggerKey struct{}
+	fieldsKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying log, for retrieval by
+// FromContext further down the call chain. Fields added to ctx by
+// WithContextFields before it carried a logger are added to log.
+func NewContext(ctx context.Context, log *zap.SugaredLogger) context.Context {
+	if fields, _ := ctx.Value(fieldsKey{}).([]any); len(fields) > 0 {
+		log = log.With(fields...)
+		ctx = context.WithValue(ctx, fieldsKey{}, []any(nil))
+	}
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext returns the logger carried by ctx, or a no-op logger if ctx
+// carries none.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	if log, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
+		return log
+	}
+	return NewNop()
+}
+
+// WithContextFields returns a copy of ctx whose logger also carries fields,
+// given as key-value pairs or zap.Fields like the Sugared Logger's With. Each
+// layer of a request can add its own, and FromContext returns a logger
+// carrying all of them. Fields added before ctx carries a logger are held
+// until NewContext stores one.
+func WithContextFields(ctx context.Context, fields ...any) context.Context {
+	if log, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
+		return context.WithValue(ctx, loggerKey{}, log.With(fields...))
+	}
+
+	prev, _ := ctx.Value(fieldsKey{}).([]any)
+	all := make([]any, 0, len(prev)+len(fields))
+	all = append(all, prev...)
+	all = append(all, fields...)
+	return context.WithValue(ctx, fieldsKey{}, all)
+}
+
+// Go runs fn in a new goroutine with ctx, so it logs through the same logger
+// and fields as the code starting it, and logs a panic in fn with that logger
+// like Recover rather than crashing the program. fn also sees ctx's
+// cancellation; pass context.WithoutCancel(ctx) for work that should outlive
+// a request.
+func Go(ctx context.Context, fn func(context.Context)) {
+	go func() {
+		defer Recover(FromContext(ctx))
+		fn(ctx)
+	}()
+}
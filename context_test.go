@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestContextFields(t *testing.T) {
+	log, buf := newBufferLogger(t)
+
+	// Fields added before and after the logger is stored are all kept.
+	ctx := WithContextFields(context.Background(), "request_id", "r1")
+	ctx = NewContext(ctx, log)
+	ctx = WithContextFields(ctx, zap.String("user", "bob"))
+	FromContext(ctx).Info("handled")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	if lines[0]["request_id"] != "r1" || lines[0]["user"] != "bob" {
+		t.Errorf("line = %v, want fields from both layers", lines[0])
+	}
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	log := FromContext(context.Background())
+	if log == nil {
+		t.Fatal("FromContext returned nil")
+	}
+	log.Info("discarded")
+}
+
+func TestGo(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	ctx := WithContextFields(NewContext(context.Background(), log), "correlation_id", "c1")
+
+	done := make(chan struct{})
+	Go(ctx, func(ctx context.Context) {
+		defer close(done)
+		FromContext(ctx).Info("working")
+	})
+	<-done
+	Go(ctx, func(context.Context) { panic("boom") })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(buf.lines()) < 2 && time.Now().Before(deadline) {
+		waitABit()
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want the work and the panic: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if line["correlation_id"] != "c1" {
+			t.Errorf("line = %v, want the context's correlation_id", line)
+		}
+	}
+	if lines[1]["msg"] != "recovered from panic" || lines[1]["panic"] != "boom" {
+		t.Errorf("line = %v, want the panic logged", lines[1])
+	}
+}
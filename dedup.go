@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithDedup collapses runs of identical entries, those with the same level,
+// logger name, message, and fields, logged within window of the first one.
+// The first entry of a run is written immediately; the rest are suppressed
+// and reported as a copy of the entry with a "repeated" field holding the
+// number suppressed. That copy is written when the window closes, or sooner
+// if a different entry arrives or the logger is synced.
+func WithDedup(window time.Duration) loggerOpt {
+	return func(b *builder) error {
+		if window <= 0 {
+			return fmt.Errorf("%w: dedup window %s must be positive", ErrInvalidOption, window)
+		}
+		state := &dedupState{window: window}
+		b.onShutdown(state.stop)
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &dedupCore{Core: core, state: state}
+		})
+		return nil
+	}
+}
+
+// dedupState tracks the run of identical entries currently being collapsed.
+// It is shared by every core derived from the same dedupCore.
+type dedupState struct {
+	mu     sync.Mutex
+	window time.Duration
+
+	pending  bool
+	key      uint64
+	start    time.Time
+	last     time.Time
+	repeated int
+	core     zapcore.Core
+	ent      zapcore.Entry
+	fields   []zapcore.Field
+
+	// timer ends the run when its window closes. run counts the runs started,
+	// so a timer that fires after its run has ended does nothing.
+	timer   *time.Timer
+	run     uint64
+	stopped bool
+}
+
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+	// context is a hash of the fields added with With, so entries logged
+	// through differently annotated loggers aren't collapsed together.
+	context uint64
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, c.context)
+	hashFields(h, fields)
+	return &dedupCore{Core: c.Core.With(fields), state: c.state, context: h.Sum64()}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := c.key(ent, fields)
+
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending && key == s.key && ent.Time.Sub(s.start) < s.window {
+		s.repeated++
+		s.last = ent.Time
+		if s.repeated == 1 {
+			s.schedule(s.window - ent.Time.Sub(s.start))
+		}
+		return nil
+	}
+
+	err := s.end()
+	s.pending = true
+	s.key = key
+	s.start = ent.Time
+	s.repeated = 0
+	s.core = c.Core
+	s.ent = ent
+	s.fields = append([]zapcore.Field(nil), fields...)
+
+	return errors.Join(err, c.Core.Write(ent, fields))
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.mu.Lock()
+	err := c.state.end()
+	c.state.mu.Unlock()
+
+	return errors.Join(err, c.Core.Sync())
+}
+
+// flush writes the summary of the current run, if anything was suppressed.
+// The caller must hold s.mu.
+func (s *dedupState) flush() error {
+	if !s.pending || s.repeated == 0 {
+		return nil
+	}
+	ent := s.ent
+	ent.Time = s.last
+	fields := append(s.fields, zap.Int("repeated", s.repeated))
+	s.repeated = 0
+	return s.core.Write(ent, fields)
+}
+
+// end flushes the current run and stops its timer. The caller must hold s.mu.
+func (s *dedupState) end() error {
+	err := s.flush()
+	s.pending = false
+	s.run++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return err
+}
+
+// schedule ends the current run after d. The caller must hold s.mu.
+func (s *dedupState) schedule(d time.Duration) {
+	if s.timer != nil || s.stopped {
+		return
+	}
+	run := s.run
+	s.timer = time.AfterFunc(d, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.run == run {
+			_ = s.end()
+		}
+	})
+}
+
+// stop ends the current run and stops any later timers, for Shutdown.
+func (s *dedupState) stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	return s.end()
+}
+
+func (c *dedupCore) key(ent zapcore.Entry, fields []zapcore.Field) uint64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, c.context)
+	_ = binary.Write(h, binary.LittleEndian, int8(ent.Level))
+	_, _ = h.Write([]byte(ent.LoggerName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(ent.Message))
+	_, _ = h.Write([]byte{0})
+	hashFields(h, fields)
+	return h.Sum64()
+}
+
+// dedupEncoder renders fields to a stable byte form for hashing.
+var dedupEncoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+
+func hashFields(h interface{ Write([]byte) (int, error) }, fields []zapcore.Field) {
+	if len(fields) == 0 {
+		return
+	}
+	buf, err := dedupEncoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return
+	}
+	_, _ = h.Write(buf.Bytes())
+	buf.Free()
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithDedup(t *testing.T) {
+	log, buf := newBufferLogger(t, WithoutSampling(), WithDedup(time.Minute))
+	for i := 0; i < 5; i++ {
+		log.Warnw("disk almost full", "disk", "sda")
+	}
+	log.Warnw("disk almost full", "disk", "sdb")
+	log.Warnw("disk almost full", "disk", "sdb")
+	log.Info("done")
+	_ = log.Sync()
+
+	lines := decodeLines(t, buf)
+	want := []struct {
+		msg      string
+		disk     any
+		repeated any
+	}{
+		{"disk almost full", "sda", nil},
+		{"disk almost full", "sda", float64(4)},
+		{"disk almost full", "sdb", nil},
+		{"disk almost full", "sdb", float64(1)},
+		{"done", nil, nil},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(want), buf.String())
+	}
+	for i, w := range want {
+		if lines[i]["msg"] != w.msg || lines[i]["disk"] != w.disk || lines[i]["repeated"] != w.repeated {
+			t.Errorf("line %d = %v, want %s on %v repeated %v", i, lines[i], w.msg, w.disk, w.repeated)
+		}
+	}
+
+	if _, err := New(TestService, WithDedup(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("zero window error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithDedupWindowCloses(t *testing.T) {
+	window := 50 * time.Millisecond
+	log, buf := newBufferLogger(t, WithoutSampling(), WithDedup(window))
+	for i := 0; i < 3; i++ {
+		log.Warn("retrying")
+	}
+
+	deadline := time.Now().Add(window + time.Second)
+	for len(buf.lines()) < 2 && time.Now().Before(deadline) {
+		waitABit()
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 || lines[1]["repeated"] != float64(2) {
+		t.Fatalf("got %q, want the first entry and a summary of 2 repeats without Sync", buf.String())
+	}
+
+	// The run has ended, so the same message starts a new one.
+	log.Warn("retrying")
+	if got := len(buf.lines()); got != 3 {
+		t.Errorf("got %d lines, want the entry after the window written", got)
+	}
+}
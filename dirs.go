@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// WithCreateDirs creates the missing parent directories of file output
+// paths, with mode 0755, before they're opened. Paths with schemes other than
+// "file", and stdout and stderr, are left alone.
+func WithCreateDirs() loggerOpt {
+	return func(b *builder) error {
+		b.createDirs = true
+		return nil
+	}
+}
+
+// makeDirs creates the parent directories of the file paths the logger will
+// open.
+func (b *builder) makeDirs() error {
+	paths := [][]string{b.cfg.OutputPaths, b.cfg.ErrorOutputPaths, b.fallbackPaths}
+	for _, r := range b.routes {
+		paths = append(paths, r.paths)
+	}
+
+	for _, ps := range paths {
+		for _, p := range ps {
+			file, ok := filePath(p)
+			if !ok {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filePath returns the file an output path refers to, the same way zap.Open
+// interprets it.
+func filePath(path string) (string, bool) {
+	if path == "stdout" || path == "stderr" {
+		return "", false
+	}
+	if filepath.IsAbs(path) {
+		return path, true
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", false
+	}
+	switch u.Scheme {
+	case "":
+		return path, true
+	case "file":
+		return u.Path, true
+	default:
+		return "", false
+	}
+}
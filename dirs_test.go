@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithCreateDirs(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "a", "b", "app.log")
+	viaURL := filepath.Join(dir, "c", "d", "app.log")
+
+	if _, err := New(TestService, WithOutputPaths(nested)); err == nil {
+		t.Fatal("New succeeded without the parent directories")
+	}
+
+	log, err := New(TestService, WithCreateDirs(), WithOutputPaths(nested, "file://"+viaURL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	log.Info("created")
+	_ = Shutdown(log)
+
+	for _, path := range []string{nested, viaURL} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+	for _, path := range []string{"stdout", "stderr", "kafka:///logs"} {
+		if file, ok := filePath(path); ok {
+			t.Errorf("filePath(%q) = %q, want it left alone", path, file)
+		}
+	}
+}
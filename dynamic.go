@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithFieldFromFunc adds a key field to every entry whose value is fn's
+// result at the time of the entry, for values that change over the life of
+// the logger. fn is called only for entries that are written, after level
+// filtering and sampling, and must be safe to call concurrently.
+func WithFieldFromFunc(key string, fn func() any) loggerOpt {
+	return func(b *builder) error {
+		if key == "" || fn == nil {
+			return fmt.Errorf("%w: field from func requires a key and a func", ErrInvalidOption)
+		}
+		b.funcFieldKeys = append(b.funcFieldKeys, key)
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, entry: funcFieldEntry(key, fn)}
+		})
+		return nil
+	}
+}
+
+// funcFieldEntry adds the key field holding fn's result to each entry.
+func funcFieldEntry(key string, fn func() any) func(zapcore.Entry, []zapcore.Field, bool) (zapcore.Entry, []zapcore.Field) {
+	return func(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+		return ent, appendField(fields, zap.Any(key, fn()))
+	}
+}
+
+// WithLevelScopedField adds a key field to entries at level or below it, and
+// only those, so detail meant for debugging isn't encoded on the busier
+// levels above. For example, WithLevelScopedField("debug", "debug_context",
+// ctx) adds the field to Debug and Trace entries but not to Info and above.
+func WithLevelScopedField(level, key string, value any) loggerOpt {
+	return func(b *builder) error {
+		if key == "" {
+			return fmt.Errorf("%w: level scoped field requires a key", ErrInvalidOption)
+		}
+		lvl, err := b.parseLevel(level)
+		if err != nil {
+			return err
+		}
+		b.funcFieldKeys = append(b.funcFieldKeys, key)
+		field := zap.Any(key, value)
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, entry: levelFieldEntry(lvl, field)}
+		})
+		return nil
+	}
+}
+
+// levelFieldEntry adds field to entries at upTo or below.
+func levelFieldEntry(upTo zapcore.Level, field zapcore.Field) func(zapcore.Entry, []zapcore.Field, bool) (zapcore.Entry, []zapcore.Field) {
+	return func(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+		if ent.Level <= upTo {
+			fields = appendField(fields, field)
+		}
+		return ent, fields
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithFieldFromFunc(t *testing.T) {
+	var calls atomic.Int64
+	next := func() any { return calls.Add(1) }
+	log, buf := newBufferLogger(t, WithFieldFromFunc("seq", next))
+
+	log.Debug("filtered")
+	log.Info("one")
+	log.With("k", "v").Info("two")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, line := range lines {
+		if line["seq"] != float64(i+1) {
+			t.Errorf("line %d seq = %v, want %d", i, line["seq"], i+1)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("fn called %d times, want only for the 2 entries written", n)
+	}
+
+	if _, err := New(TestService, WithFieldFromFunc("seq", nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("nil func error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithLevelScopedField(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLevel("trace"), WithLevelScopedField("debug", "debug_context", map[string]any{"cache": "cold"}))
+	Trace(log, "tracing")
+	log.Debug("details")
+	log.Info("hello")
+	log.Error("failed")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(lines))
+	}
+	for i, scoped := range []bool{true, true, false, false} {
+		_, ok := lines[i]["debug_context"]
+		if ok != scoped {
+			t.Errorf("line %d = %v, want debug_context only at debug and below", i, lines[i])
+		}
+	}
+	if ctx, _ := lines[1]["debug_context"].(map[string]any); ctx["cache"] != "cold" {
+		t.Errorf("debug_context = %v, want the given value", lines[1]["debug_context"])
+	}
+
+	if _, err := New(TestService, WithLevelScopedField("debug", "", 1)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("empty key error = %v, want ErrInvalidOption", err)
+	}
+	if _, err := New(TestService, WithLevelScopedField("loud", "k", 1)); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("unknown level error = %v, want ErrUnknownLevel", err)
+	}
+}
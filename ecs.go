@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ecsEncoding = "ecs"
+	// ecsVersion is the version of the Elastic Common Schema the output
+	// follows.
+	ecsVersion = "8.11.0"
+)
+
+var (
+	ecsOnce sync.Once
+	ecsErr  error
+)
+
+// WithECSMapping switches to output following the Elastic Common Schema: the
+// time under "@timestamp", the lower-case level under "log.level", the message
+// under "message", the logger name under "log.logger", and stack traces under
+// "error.stack_trace". The caller is reported as a "log.origin" object holding
+// "file.name", "file.line", and "function", and every entry carries
+// "ecs.version".
+func WithECSMapping() loggerOpt {
+	return func(b *builder) error {
+		ecsOnce.Do(func() {
+			ecsErr = zap.RegisterEncoder(ecsEncoding, newECSEncoder)
+		})
+		if ecsErr != nil {
+			return ecsErr
+		}
+
+		cfg := &b.cfg
+		cfg.Encoding = ecsEncoding
+		cfg.EncoderConfig.TimeKey = "@timestamp"
+		cfg.EncoderConfig.LevelKey = "log.level"
+		cfg.EncoderConfig.NameKey = "log.logger"
+		cfg.EncoderConfig.MessageKey = "message"
+		cfg.EncoderConfig.StacktraceKey = "error.stack_trace"
+		cfg.EncoderConfig.LineEnding = zapcore.DefaultLineEnding
+		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		cfg.EncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		cfg.EncoderConfig.EncodeDuration = zapcore.NanosDurationEncoder
+		return nil
+	}
+}
+
+// ecsEncoder wraps the JSON encoder, moving the caller into a "log.origin"
+// object and adding "ecs.version" to each entry.
+type ecsEncoder struct {
+	zapcore.Encoder
+	caller bool
+}
+
+func newECSEncoder(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	caller := cfg.CallerKey != zapcore.OmitKey && cfg.CallerKey != ""
+	cfg.CallerKey = zapcore.OmitKey
+	cfg.FunctionKey = zapcore.OmitKey
+	return &ecsEncoder{Encoder: zapcore.NewJSONEncoder(cfg), caller: caller}, nil
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{Encoder: e.Encoder.Clone(), caller: e.caller}
+}
+
+func (e *ecsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fs := make([]zapcore.Field, 0, len(fields)+2)
+	fs = append(fs, zap.String("ecs.version", ecsVersion))
+	if e.caller && ent.Caller.Defined {
+		fs = append(fs, zap.Object("log.origin", ecsOrigin(ent.Caller)))
+	}
+	fs = append(fs, fields...)
+	return e.Encoder.EncodeEntry(ent, fs)
+}
+
+// ecsOrigin marshals a caller as an ECS log.origin object.
+type ecsOrigin zapcore.EntryCaller
+
+func (o ecsOrigin) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if err := enc.AddObject("file", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("name", filepath.Base(o.File))
+		enc.AddInt("line", o.Line)
+		return nil
+	})); err != nil {
+		return err
+	}
+	if o.Function != "" {
+		enc.AddString("function", o.Function)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWithECSMapping(t *testing.T) {
+	log, buf := newBufferLogger(t, WithECSMapping(), WithStacktrace("error"))
+	_, _, line, _ := runtime.Caller(0)
+	Named(log, "orders").Error("failed")
+
+	entry := decodeLines(t, buf)[0]
+	want := map[string]any{
+		"ecs.version": ecsVersion,
+		"log.level":   "error",
+		"log.logger":  "orders",
+		"message":     "failed",
+		"service":     TestService,
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["@timestamp"].(string); !ok {
+		t.Errorf("@timestamp = %v, want a string", entry["@timestamp"])
+	}
+	if st, _ := entry["error.stack_trace"].(string); !strings.Contains(st, "TestWithECSMapping") {
+		t.Errorf("error.stack_trace = %q, want this test in it", st)
+	}
+	for _, key := range []string{"caller", "level", "msg", "ts", "stacktrace"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("entry still has zap's %q key", key)
+		}
+	}
+
+	origin, _ := entry["log.origin"].(map[string]any)
+	if origin == nil {
+		t.Fatalf("log.origin = %v, want an object", entry["log.origin"])
+	}
+	file, _ := origin["file"].(map[string]any)
+	if file["name"] != "ecs_test.go" || file["line"] != float64(line+1) {
+		t.Errorf("log.origin = %v, want ecs_test.go:%d", origin, line+1)
+	}
+	if fn, _ := origin["function"].(string); !strings.HasSuffix(fn, "TestWithECSMapping") {
+		t.Errorf("log.origin.function = %q, want this test", fn)
+	}
+}
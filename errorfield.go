@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorField returns an "error" field describing err in full: its message
+// under "message", the message of each error in its chain, outermost first,
+// under "chain", and the stack trace of the innermost error that has one,
+// such as those created by github.com/pkg/errors, under "stacktrace". Pass it
+// to the Sugared Logger like any other field:
+//
+//	log.Errorw("saving order", logger.ErrorField(err))
+func ErrorField(err error) zap.Field {
+	return NamedErrorField("error", err)
+}
+
+// NamedErrorField behaves like ErrorField with a key other than "error".
+func NamedErrorField(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, errorChain{err})
+}
+
+// errorChain marshals an error and the errors it wraps.
+type errorChain struct {
+	err error
+}
+
+func (e errorChain) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", e.err.Error())
+
+	chain := unwrapAll(e.err)
+	if err := enc.AddArray("chain", zapcore.ArrayMarshalerFunc(func(ae zapcore.ArrayEncoder) error {
+		for _, err := range chain {
+			ae.AppendString(err.Error())
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if stack, ok := stackTrace(chain[i]); ok {
+			enc.AddString("stacktrace", stack)
+			break
+		}
+	}
+	return nil
+}
+
+// unwrapAll returns err and every error it wraps, depth first, including each
+// error joined by errors.Join.
+func unwrapAll(err error) []error {
+	var chain []error
+	for err != nil {
+		chain = append(chain, err)
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				chain = append(chain, unwrapAll(err)...)
+			}
+			break
+		}
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// stackTrace formats the stack of errors with a StackTrace method, such as
+// those created by github.com/pkg/errors, without depending on the package
+// providing it.
+func stackTrace(err error) (string, bool) {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return "", false
+	}
+	return fmt.Sprintf("%+v", m.Call(nil)[0].Interface()), true
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// tracedError carries a stack trace the way github.com/pkg/errors does.
+type tracedError struct{ msg string }
+
+func (e tracedError) Error() string { return e.msg }
+
+func (e tracedError) StackTrace() fakeStack { return "main.go:12" }
+
+type fakeStack string
+
+func TestErrorField(t *testing.T) {
+	root := tracedError{"connection refused"}
+	err := fmt.Errorf("saving order: %w", fmt.Errorf("querying db: %w", root))
+
+	log, buf := newBufferLogger(t)
+	log.Errorw("failed", ErrorField(err), NamedErrorField("cause", nil))
+
+	line := decodeLines(t, buf)[0]
+	if _, ok := line["cause"]; ok {
+		t.Errorf("nil error field was written: %v", line)
+	}
+	field, ok := line["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("error = %v, want an object", line["error"])
+	}
+	if field["message"] != err.Error() || field["stacktrace"] != "main.go:12" {
+		t.Errorf("error = %v, want the message and the root's stack trace", field)
+	}
+	want := []any{err.Error(), "querying db: connection refused", "connection refused"}
+	if chain, _ := field["chain"].([]any); fmt.Sprint(chain) != fmt.Sprint(want) {
+		t.Errorf("chain = %q, want %q", chain, want)
+	}
+}
+
+func TestErrorFieldJoined(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	log, buf := newBufferLogger(t)
+	log.Errorw("failed", ErrorField(fmt.Errorf("both: %w", errors.Join(a, b))))
+
+	field := decodeLines(t, buf)[0]["error"].(map[string]any)
+	want := []any{"both: a\nb", "a\nb", "a", "b"}
+	if chain, _ := field["chain"].([]any); fmt.Sprint(chain) != fmt.Sprint(want) {
+		t.Errorf("chain = %q, want the wrapper, the join, and each joined error", chain)
+	}
+}
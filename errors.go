@@ -0,0 +1,22 @@
+package logger
+
+import "errors"
+
+// Errors returned when an option or constructor is given an invalid value.
+// They're wrapped with the offending value, so test for them with errors.Is.
+var (
+	// ErrEmptyService is returned by the constructors for a blank service.
+	ErrEmptyService = errors.New("logger: service must not be empty")
+	// ErrUnknownLevel is returned for a level name that isn't recognized.
+	ErrUnknownLevel = errors.New("logger: unknown level")
+	// ErrUnknownFormat is returned for an unrecognized encoding, time format,
+	// or duration format.
+	ErrUnknownFormat = errors.New("logger: unknown format")
+	// ErrReservedField is returned when an option would overwrite a field
+	// this package sets, such as "service", or one the encoder writes, such as
+	// the message.
+	ErrReservedField = errors.New("logger: reserved field")
+	// ErrInvalidOption is returned for any other value an option can't use,
+	// such as a negative size.
+	ErrInvalidOption = errors.New("logger: invalid option")
+)
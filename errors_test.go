@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     loggerOpt
+		want    error
+		mention string
+	}{
+		{"level", WithLevel("verbose"), ErrUnknownLevel, `"verbose"`},
+		{"encoding", WithEncoding("yaml"), ErrUnknownFormat, `"yaml"`},
+		{"time format", WithTimeEncoder("sundial"), ErrUnknownFormat, `"sundial"`},
+		{"reserved field", WithFields(map[string]any{"service": "x"}), ErrReservedField, `"service"`},
+		{"sampling", WithSampling(-1, 1), ErrInvalidOption, "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(TestService, tt.opt)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.mention) {
+				t.Errorf("error %q doesn't mention %s", err, tt.mention)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var newlineEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// WithNewlineEscaping protects console output from log forging by escaping
+// newlines and carriage returns in the message and logger name, which the
+// console encoder writes verbatim. Field values are already escaped by the
+// console encoder, as is everything in JSON output, so the option has no
+// effect unless the encoding is "console" when the logger is built.
+func WithNewlineEscaping() loggerOpt {
+	return func(b *builder) error {
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			if b.cfg.Encoding != "console" {
+				return core
+			}
+			return &rewriteCore{Core: core, entry: escapeEntry}
+		})
+		return nil
+	}
+}
+
+// escapeEntry escapes newlines in the message and logger name.
+func escapeEntry(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+	ent.Message = newlineEscaper.Replace(ent.Message)
+	ent.LoggerName = newlineEscaper.Replace(ent.LoggerName)
+	return ent, fields
+}
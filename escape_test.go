@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithNewlineEscaping(t *testing.T) {
+	forged := "login failed\nFAKE level=error\r"
+	log, buf := newBufferLogger(t, WithEncoding("console"), WithNewlineEscaping())
+	log.Named("auth\nx").Infow(forged, "user", "bob\nFAKE")
+
+	out := buf.String()
+	if n := strings.Count(out, "\n"); n != 1 {
+		t.Fatalf("got %d lines, want 1: %q", n, out)
+	}
+	for _, want := range []string{`login failed\nFAKE level=error\r`, `auth\nx`, `bob\nFAKE`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q is missing %q", out, want)
+		}
+	}
+
+	// JSON output is escaped already and left alone.
+	log, buf = newBufferLogger(t, WithNewlineEscaping())
+	log.Info(forged)
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["msg"] != forged {
+		t.Errorf("json output = %q", buf.String())
+	}
+}
@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/funayman/logger"
+)
+
+func ExampleNewTest() {
+	log, logs := logger.NewTest()
+
+	log.Infow("user created", "id", 42)
+	log.Warn("disk almost full")
+	log.Infow("user created", "id", 43)
+
+	for _, e := range logs.FilterMessage("user created").All() {
+		fmt.Println(e.Level, e.Message, e.ContextMap()["id"], e.ContextMap()["service"])
+	}
+	fmt.Println(logs.Len(), "entries")
+	// Output:
+	// info user created 42 test
+	// info user created 43 test
+	// 3 entries
+}
+
+func ExampleDesugar() {
+	log, logs := logger.NewTest()
+
+	// On hot paths, desugar once and log typed fields.
+	fast := logger.Desugar(log)
+	for status := 200; status < 202; status++ {
+		fast.Info("request", zap.String("path", "/"), zap.Int("status", status))
+	}
+
+	for _, e := range logs.All() {
+		fmt.Println(e.Message, e.ContextMap()["status"], e.ContextMap()["service"])
+	}
+	// Output:
+	// request 200 test
+	// request 201 test
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// gcpReportedErrorEvent is the type that tells Google Cloud Error Reporting a
+// log entry describes an error.
+const gcpReportedErrorEvent = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// WithGCPErrorReporting applies WithGCPMapping and formats entries at Error
+// and above so Google Cloud Error Reporting picks them up: each carries the
+// ReportedErrorEvent "@type", a "serviceContext" naming service and version,
+// and the caller as "context.reportLocation", and the stack trace is moved
+// into the message, where Error Reporting looks for it. Stack traces are
+// recorded for these entries, overriding an earlier WithStacktrace.
+func WithGCPErrorReporting(service, version string) loggerOpt {
+	return func(b *builder) error {
+		if service == "" {
+			return fmt.Errorf("%w: error reporting requires a service", ErrInvalidOption)
+		}
+		if err := WithGCPMapping()(b); err != nil {
+			return err
+		}
+		b.cfg.DisableStacktrace = false
+		b.zapOpts = append(b.zapOpts, zap.AddStacktrace(zapcore.ErrorLevel))
+
+		serviceContext := zap.Object("serviceContext", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("service", service)
+			if version != "" {
+				enc.AddString("version", version)
+			}
+			return nil
+		}))
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, entry: errorReportingEntry(serviceContext)}
+		})
+		return nil
+	}
+}
+
+// errorReportingEntry adds the fields Error Reporting needs to error entries.
+func errorReportingEntry(serviceContext zap.Field) func(zapcore.Entry, []zapcore.Field, bool) (zapcore.Entry, []zapcore.Field) {
+	return func(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+		if ent.Level < zapcore.ErrorLevel {
+			return ent, fields
+		}
+
+		fs := make([]zapcore.Field, 0, len(fields)+3)
+		fs = append(fs, zap.String("@type", gcpReportedErrorEvent), serviceContext)
+		if ent.Caller.Defined {
+			fs = append(fs, zap.Object("context", gcpErrorContext(ent.Caller)))
+		}
+		fs = append(fs, fields...)
+
+		if ent.Stack != "" {
+			ent.Message += "\n\ngoroutine 1 [running]:\n" + ent.Stack
+			ent.Stack = ""
+		}
+		return ent, fs
+	}
+}
+
+// gcpErrorContext marshals a caller as the context of a reported error.
+type gcpErrorContext zapcore.EntryCaller
+
+func (c gcpErrorContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return enc.AddObject("reportLocation", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("filePath", c.File)
+		enc.AddInt("lineNumber", c.Line)
+		if c.Function != "" {
+			enc.AddString("functionName", c.Function)
+		}
+		return nil
+	}))
+}
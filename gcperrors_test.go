@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithGCPErrorReporting(t *testing.T) {
+	log, buf := newBufferLogger(t, WithGCPErrorReporting("checkout", "1.4.0"))
+	log.Warn("careful")
+	log.Errorw("payment failed", "order", 7)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	warn, failed := lines[0], lines[1]
+	if _, ok := warn["@type"]; ok || warn["message"] != "careful" {
+		t.Errorf("warning = %v, want it left as a plain entry", warn)
+	}
+
+	if failed["@type"] != gcpReportedErrorEvent || failed["severity"] != "ERROR" || failed["order"] != float64(7) {
+		t.Errorf("error = %v, want the ReportedErrorEvent type and its own fields", failed)
+	}
+	sc, _ := failed["serviceContext"].(map[string]any)
+	if sc["service"] != "checkout" || sc["version"] != "1.4.0" {
+		t.Errorf("serviceContext = %v, want checkout 1.4.0", sc)
+	}
+	ctx, _ := failed["context"].(map[string]any)
+	loc, _ := ctx["reportLocation"].(map[string]any)
+	if file, _ := loc["filePath"].(string); !strings.HasSuffix(file, "gcperrors_test.go") || loc["lineNumber"] == nil {
+		t.Errorf("reportLocation = %v, want this file and a line", loc)
+	}
+	msg, _ := failed["message"].(string)
+	if !strings.HasPrefix(msg, "payment failed\n\ngoroutine 1 [running]:\n") || !strings.Contains(msg, "TestWithGCPErrorReporting") {
+		t.Errorf("message = %q, want the stack trace appended", msg)
+	}
+	if _, ok := failed["stacktrace"]; ok {
+		t.Errorf("error = %v, want the stack trace moved into the message", failed)
+	}
+
+	if _, err := New(TestService, WithGCPErrorReporting("", "1")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("empty service error = %v, want ErrInvalidOption", err)
+	}
+}
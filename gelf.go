@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const gelfEncoding = "gelf"
+
+var (
+	gelfOnce sync.Once
+	gelfErr  error
+)
+
+// WithGELF switches the logger to GELF 1.1 output for Graylog. Each entry
+// carries "version", "host", "short_message", a "timestamp" in fractional
+// Unix seconds, and a numeric syslog "level". Every other field, including
+// "service", is prefixed with an underscore as GELF requires for additional
+// fields. Stack traces are sent as "full_message".
+func WithGELF() loggerOpt {
+	return func(b *builder) error {
+		gelfOnce.Do(func() {
+			gelfErr = zap.RegisterEncoder(gelfEncoding, newGELFEncoder)
+		})
+		if gelfErr != nil {
+			return gelfErr
+		}
+
+		cfg := &b.cfg
+		cfg.Encoding = gelfEncoding
+		cfg.EncoderConfig.TimeKey = "timestamp"
+		cfg.EncoderConfig.LevelKey = "level"
+		cfg.EncoderConfig.NameKey = "_logger"
+		cfg.EncoderConfig.CallerKey = "_caller"
+		cfg.EncoderConfig.FunctionKey = zapcore.OmitKey
+		cfg.EncoderConfig.MessageKey = "short_message"
+		cfg.EncoderConfig.StacktraceKey = "full_message"
+		cfg.EncoderConfig.LineEnding = zapcore.DefaultLineEnding
+		cfg.EncoderConfig.EncodeTime = zapcore.EpochTimeEncoder
+		cfg.EncoderConfig.EncodeLevel = func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendInt(syslogSeverity(l))
+		}
+		return nil
+	}
+}
+
+// gelfEncoder wraps the JSON encoder, prefixing the key of every field with an
+// underscore and adding the fixed GELF fields to each entry.
+type gelfEncoder struct {
+	zapcore.Encoder
+	host string
+}
+
+func newGELFEncoder(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &gelfEncoder{Encoder: zapcore.NewJSONEncoder(cfg), host: host}, nil
+}
+
+func (e *gelfEncoder) Clone() zapcore.Encoder {
+	return &gelfEncoder{Encoder: e.Encoder.Clone(), host: e.host}
+}
+
+func (e *gelfEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fs := make([]zapcore.Field, 0, len(fields)+2)
+	fs = append(fs, zap.String("version", "1.1"), zap.String("host", e.host))
+	for _, f := range fields {
+		f.Key = "_" + f.Key
+		fs = append(fs, f)
+	}
+	return e.Encoder.EncodeEntry(ent, fs)
+}
+
+func (e *gelfEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	return e.Encoder.AddArray("_"+key, v)
+}
+
+func (e *gelfEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	return e.Encoder.AddObject("_"+key, v)
+}
+
+func (e *gelfEncoder) AddBinary(key string, v []byte)     { e.Encoder.AddBinary("_"+key, v) }
+func (e *gelfEncoder) AddByteString(key string, v []byte) { e.Encoder.AddByteString("_"+key, v) }
+func (e *gelfEncoder) AddBool(key string, v bool)         { e.Encoder.AddBool("_"+key, v) }
+func (e *gelfEncoder) AddComplex128(key string, v complex128) {
+	e.Encoder.AddComplex128("_"+key, v)
+}
+func (e *gelfEncoder) AddComplex64(key string, v complex64) {
+	e.Encoder.AddComplex64("_"+key, v)
+}
+func (e *gelfEncoder) AddDuration(key string, v time.Duration) {
+	e.Encoder.AddDuration("_"+key, v)
+}
+func (e *gelfEncoder) AddFloat64(key string, v float64) { e.Encoder.AddFloat64("_"+key, v) }
+func (e *gelfEncoder) AddFloat32(key string, v float32) { e.Encoder.AddFloat32("_"+key, v) }
+func (e *gelfEncoder) AddInt(key string, v int)         { e.Encoder.AddInt("_"+key, v) }
+func (e *gelfEncoder) AddInt64(key string, v int64)     { e.Encoder.AddInt64("_"+key, v) }
+func (e *gelfEncoder) AddInt32(key string, v int32)     { e.Encoder.AddInt32("_"+key, v) }
+func (e *gelfEncoder) AddInt16(key string, v int16)     { e.Encoder.AddInt16("_"+key, v) }
+func (e *gelfEncoder) AddInt8(key string, v int8)       { e.Encoder.AddInt8("_"+key, v) }
+func (e *gelfEncoder) AddString(key, v string)          { e.Encoder.AddString("_"+key, v) }
+func (e *gelfEncoder) AddTime(key string, v time.Time)  { e.Encoder.AddTime("_"+key, v) }
+func (e *gelfEncoder) AddUint(key string, v uint)       { e.Encoder.AddUint("_"+key, v) }
+func (e *gelfEncoder) AddUint64(key string, v uint64)   { e.Encoder.AddUint64("_"+key, v) }
+func (e *gelfEncoder) AddUint32(key string, v uint32)   { e.Encoder.AddUint32("_"+key, v) }
+func (e *gelfEncoder) AddUint16(key string, v uint16)   { e.Encoder.AddUint16("_"+key, v) }
+func (e *gelfEncoder) AddUint8(key string, v uint8)     { e.Encoder.AddUint8("_"+key, v) }
+func (e *gelfEncoder) AddUintptr(key string, v uintptr) { e.Encoder.AddUintptr("_"+key, v) }
+func (e *gelfEncoder) AddReflected(key string, v any) error {
+	return e.Encoder.AddReflected("_"+key, v)
+}
+func (e *gelfEncoder) OpenNamespace(key string) { e.Encoder.OpenNamespace("_" + key) }
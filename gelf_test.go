@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWithGELF(t *testing.T) {
+	log, buf := newBufferLogger(t, WithGELF())
+	log.With("request", "abc").Warnw("slow", "ms", 250)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	line := lines[0]
+	host, _ := os.Hostname()
+	want := map[string]any{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": "slow",
+		"level":         float64(4),
+		"_service":      TestService,
+		"_request":      "abc",
+		"_ms":           float64(250),
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+	if ts, ok := line["timestamp"].(float64); !ok || ts <= 0 {
+		t.Errorf("timestamp = %v, want Unix seconds", line["timestamp"])
+	}
+	for k := range line {
+		switch k {
+		case "version", "host", "short_message", "full_message", "timestamp", "level":
+		default:
+			if k[0] != '_' {
+				t.Errorf("additional field %q isn't prefixed with an underscore", k)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zapgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// NewGRPCLogger adapts log to grpclog.LoggerV2 so gRPC's own logging, such as
+// connection and balancer events, goes through it. Only entries at or above
+// level, which accepts the same names as WithLevel, are written, since gRPC
+// logs a lot at Info. Install it with grpclog.SetLoggerV2 before making any
+// other gRPC calls.
+func NewGRPCLogger(log *zap.SugaredLogger, level string) (grpclog.LoggerV2, error) {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	l := log.Desugar().WithOptions(zap.AddCallerSkip(1))
+	if lvl > zapcore.LevelOf(l.Core()) {
+		l = l.WithOptions(zap.IncreaseLevel(lvl))
+	}
+	return zapgrpc.NewLogger(l), nil
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that logs the
+// full method, status code, and duration of every unary RPC. The level is
+// chosen from the status code, see `codeLevel()`.
+func UnaryServerInterceptor(log *zap.SugaredLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRPC(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that logs the
+// full method, status code, and duration of every streaming RPC once the
+// stream completes.
+func StreamServerInterceptor(log *zap.SugaredLogger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRPC(ss.Context(), log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logRPC(ctx context.Context, log *zap.SugaredLogger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []any{
+		"method", method,
+		"code", code.String(),
+		"duration", time.Since(start),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(strings.ToLower(RequestIDHeader)); len(ids) > 0 {
+			fields = append(fields, "request_id", ids[0])
+		}
+	}
+	if err != nil {
+		fields = append(fields, "error", err)
+	}
+
+	switch codeLevel(code) {
+	case zapcore.ErrorLevel:
+		log.Errorw("rpc", fields...)
+	case zapcore.WarnLevel:
+		log.Warnw("rpc", fields...)
+	default:
+		log.Infow("rpc", fields...)
+	}
+}
+
+// codeLevel maps a gRPC status code to the level its RPC is logged at. Codes
+// caused by the client are Info, conditions worth watching are Warn, and
+// server faults are Error.
+func codeLevel(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return zapcore.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
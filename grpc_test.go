@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialHealth serves the gRPC health service over an in-memory connection
+// with the interceptors logging to log.
+func dialHealth(t *testing.T, srv *grpc.Server) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	hs := health.NewServer()
+	hs.SetServingStatus("up", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	log, logs := NewTest()
+	client := dialHealth(t, grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor(log))))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDHeader, "abc")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "up"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "down"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Check(down) error = %v, want NotFound", err)
+	}
+
+	entries := logs.FilterMessage("rpc").All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d rpc entries, want 2", len(entries))
+	}
+	for i, code := range []string{"OK", "NotFound"} {
+		fields := entries[i].ContextMap()
+		if fields["method"] != "/grpc.health.v1.Health/Check" || fields["code"] != code || fields["request_id"] != "abc" {
+			t.Errorf("entry %d fields = %v, want code %s", i, fields, code)
+		}
+		if entries[i].Level != zapcore.InfoLevel {
+			t.Errorf("entry %d level = %s, want info", i, entries[i].Level)
+		}
+	}
+	if _, ok := entries[1].ContextMap()["error"]; !ok {
+		t.Error("failed RPC has no error field")
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	log, logs := NewTest()
+	client := dialHealth(t, grpc.NewServer(grpc.StreamInterceptor(StreamServerInterceptor(log))))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// The server logs once it sees the stream end.
+	for i := 0; i < 100 && logs.FilterMessage("rpc").Len() == 0; i++ {
+		waitABit()
+	}
+	entries := logs.FilterMessage("rpc").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d rpc entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["method"] != "/grpc.health.v1.Health/Watch" || fields["code"] != "Canceled" {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestCodeLevel(t *testing.T) {
+	tests := map[codes.Code]zapcore.Level{
+		codes.OK:                zapcore.InfoLevel,
+		codes.NotFound:          zapcore.InfoLevel,
+		codes.Unavailable:       zapcore.WarnLevel,
+		codes.DeadlineExceeded:  zapcore.WarnLevel,
+		codes.Internal:          zapcore.ErrorLevel,
+		codes.Unknown:           zapcore.ErrorLevel,
+		codes.DataLoss:          zapcore.ErrorLevel,
+		codes.PermissionDenied:  zapcore.WarnLevel,
+		codes.InvalidArgument:   zapcore.InfoLevel,
+		codes.Unimplemented:     zapcore.ErrorLevel,
+		codes.ResourceExhausted: zapcore.WarnLevel,
+	}
+	for code, want := range tests {
+		if got := codeLevel(code); got != want {
+			t.Errorf("codeLevel(%s) = %s, want %s", code, got, want)
+		}
+	}
+}
+
+func TestNewGRPCLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	glog, err := NewGRPCLogger(zap.New(core).Sugar(), "warn")
+	if err != nil {
+		t.Fatalf("NewGRPCLogger: %v", err)
+	}
+	glog.Info("connecting")
+	glog.Warningf("retrying %s", "dns")
+	glog.Errorln("balancer", "failed")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the warning and the error", len(entries))
+	}
+	if entries[0].Level != zap.WarnLevel || entries[0].Message != "retrying dns" {
+		t.Errorf("entry 0 = %s %q, want warn %q", entries[0].Level, entries[0].Message, "retrying dns")
+	}
+	if entries[1].Level != zap.ErrorLevel || !strings.Contains(entries[1].Message, "balancer") {
+		t.Errorf("entry 1 = %s %q, want an error about the balancer", entries[1].Level, entries[1].Message)
+	}
+
+	if _, err := NewGRPCLogger(zap.New(core).Sugar(), "loud"); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("unknown level error = %v, want ErrUnknownLevel", err)
+	}
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithFieldHashing replaces the value of string fields whose key matches one
+// of keys with the hex SHA-256 digest of salt followed by the value, so
+// entries about the same user, say, can be correlated without logging the
+// value itself. Keys are matched case-insensitively against top-level fields;
+// fields of other types are left alone.
+func WithFieldHashing(keys []string, salt string) loggerOpt {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+
+	return func(b *builder) error {
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, field: hashField(set, salt)}
+		})
+		return nil
+	}
+}
+
+// hashField replaces the value of string fields whose key is in keys with
+// its salted digest.
+func hashField(keys map[string]struct{}, salt string) func(zapcore.Field) (zapcore.Field, fieldEdit) {
+	return func(f zapcore.Field) (zapcore.Field, fieldEdit) {
+		if f.Type != zapcore.StringType {
+			return f, fieldKept
+		}
+		if _, ok := keys[strings.ToLower(f.Key)]; !ok {
+			return f, fieldKept
+		}
+		sum := sha256.Sum256([]byte(salt + f.String))
+		f.String = hex.EncodeToString(sum[:])
+		return f, fieldReplaced
+	}
+}
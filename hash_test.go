@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestWithFieldHashing(t *testing.T) {
+	log, buf := newBufferLogger(t, WithFieldHashing([]string{"Email"}, "pepper"))
+	log.Infow("signup", "email", "bob@example.com", "age", 42)
+	log.With("EMAIL", "bob@example.com").Info("login")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	sum := sha256.Sum256([]byte("pepper" + "bob@example.com"))
+	want := hex.EncodeToString(sum[:])
+	if lines[0]["email"] != want || lines[1]["EMAIL"] != want {
+		t.Errorf("hashes = %v and %v, want both %s", lines[0]["email"], lines[1]["EMAIL"], want)
+	}
+	if lines[0]["age"] != float64(42) {
+		t.Errorf("age = %v, want it left alone", lines[0]["age"])
+	}
+
+	log, buf = newBufferLogger(t, WithFieldHashing([]string{"email"}, "salt"))
+	log.Infow("signup", "email", "bob@example.com")
+	if other := decodeLines(t, buf)[0]["email"]; other == want {
+		t.Error("a different salt gave the same hash")
+	}
+}
@@ -0,0 +1,286 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+type errorPayload struct {
+	Error string `json:"error"`
+}
+
+// LevelHandler returns an http.Handler that reports and updates the provided
+// level, mirroring zap.AtomicLevel's ServeHTTP. A GET request returns the
+// current level as `{"level":"info"}` and a PUT request with the same body
+// changes it. Level names are case-insensitive and match those accepted by
+// `WithLevel()`.
+func LevelHandler(level zap.AtomicLevel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var req levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorPayload{fmt.Sprintf("request body must be well-formed JSON: %v", err)})
+				return
+			}
+			lvl, err := parseLevel(req.Level)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, errorPayload{err.Error()})
+				return
+			}
+			level.SetLevel(lvl)
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, errorPayload{"only GET and PUT are supported"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, levelPayload{levelName(level.Level())})
+	})
+}
+
+// levelName returns the lowercase name used in logLevels for lvl.
+func levelName(lvl zapcore.Level) string {
+	for name, l := range logLevels {
+		if l == lvl {
+			return strings.ToLower(name)
+		}
+	}
+	return lvl.String()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// RequestIDHeader is the header Middleware reads the request ID from.
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware returns net/http middleware that logs one line per request with
+// the method, path, status, response size, and duration. Responses with a 4xx
+// status are logged at Warn, 5xx at Error, and everything else at Info. A
+// panic in the wrapped handler is always logged at Error, and answered with a
+// 500 unless the handler had already sent its headers.
+func Middleware(log *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			defer func() {
+				fields := []any{
+					"method", r.Method,
+					"path", r.URL.Path,
+				}
+				if id := r.Header.Get(RequestIDHeader); id != "" {
+					fields = append(fields, "request_id", id)
+				}
+
+				rec := recover()
+				if rec != nil {
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+					if !rw.wroteHeader {
+						rw.WriteHeader(http.StatusInternalServerError)
+					}
+					fields = append(fields, "panic", rec)
+				}
+
+				fields = append(fields,
+					"status", rw.status,
+					"size", rw.size,
+					"duration", time.Since(start),
+				)
+
+				switch {
+				case rec != nil, rw.status >= http.StatusInternalServerError:
+					log.Errorw("request", fields...)
+				case rw.status >= http.StatusBadRequest:
+					log.Warnw("request", fields...)
+				default:
+					log.Infow("request", fields...)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RequestIDMiddleware behaves like Middleware but makes sure every request
+// has an ID: one is generated when the request has no RequestIDHeader. The ID
+// is echoed in the response's RequestIDHeader, logged on the access line, and
+// carried as "request_id" by the logger stored in the request context for
+// handlers to retrieve with FromContext.
+func RequestIDMiddleware(log *zap.SugaredLogger) func(http.Handler) http.Handler {
+	access := Middleware(log)
+	return func(next http.Handler) http.Handler {
+		logged := access(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+
+			r = r.Clone(NewContext(r.Context(), log.With("request_id", id)))
+			r.Header.Set(RequestIDHeader, id)
+			w.Header().Set(RequestIDHeader, id)
+
+			logged.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// responseWriter records the status code and number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.status = status
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the underlying writer
+// supports it, so streaming handlers behind Middleware still work.
+func (rw *responseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack lets the handler take over the connection, for websockets and the
+// like, if the underlying writer supports it.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// NewLoggingRoundTripper returns an http.RoundTripper that sends requests
+// through next, or http.DefaultTransport if next is nil, and logs one line per
+// request with the method, host, path, status, response size, and duration,
+// at the levels Middleware uses. The line is written once the response body
+// is read to the end or closed, so the size and duration cover the body, and
+// at once for requests that fail. Headers and the query string are left out
+// so credentials such as the Authorization header are never logged.
+//
+//	client := &http.Client{Transport: logger.NewLoggingRoundTripper(nil, log)}
+func NewLoggingRoundTripper(next http.RoundTripper, log *zap.SugaredLogger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingRoundTripper{next: next, log: log}
+}
+
+type loggingRoundTripper struct {
+	next http.RoundTripper
+	log  *zap.SugaredLogger
+}
+
+func (t *loggingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	fields := []any{
+		"method", r.Method,
+		"host", r.URL.Host,
+		"path", r.URL.Path,
+	}
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		t.log.Errorw("client request", append(fields,
+			"error", err,
+			"duration", time.Since(start),
+		)...)
+		return resp, err
+	}
+
+	resp.Body = &loggedBody{ReadCloser: resp.Body, done: func(size int64) {
+		fields := append(fields,
+			"status", resp.StatusCode,
+			"size", size,
+			"duration", time.Since(start),
+		)
+		switch {
+		case resp.StatusCode >= http.StatusInternalServerError:
+			t.log.Errorw("client request", fields...)
+		case resp.StatusCode >= http.StatusBadRequest:
+			t.log.Warnw("client request", fields...)
+		default:
+			t.log.Infow("client request", fields...)
+		}
+	}}
+	return resp, nil
+}
+
+// loggedBody counts the bytes read from a response body and calls done with
+// the count when the body is exhausted or closed, whichever comes first.
+type loggedBody struct {
+	io.ReadCloser
+	size int64
+	once sync.Once
+	done func(size int64)
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.size) })
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.size) })
+	return err
+}
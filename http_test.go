@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLevelHandler(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	h := LevelHandler(level)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantLevel  string
+	}{
+		{"get", http.MethodGet, "", http.StatusOK, `{"level":"info"}`, "info"},
+		{"put", http.MethodPut, `{"level":"TRACE"}`, http.StatusOK, `{"level":"trace"}`, "trace"},
+		{"put unknown level", http.MethodPut, `{"level":"loud"}`, http.StatusBadRequest, "unknown level", "trace"},
+		{"put malformed", http.MethodPut, `{`, http.StatusBadRequest, "well-formed JSON", "trace"},
+		{"post", http.MethodPost, "", http.StatusMethodNotAllowed, "only GET and PUT", "trace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/level", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if got := levelName(level.Level()); got != tt.wantLevel {
+				t.Errorf("level = %s, want %s", got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("hello")) })
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	mux.HandleFunc("/late-panic", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	})
+	srv := httptest.NewServer(Middleware(log)(mux))
+	defer srv.Close()
+
+	for _, path := range []string{"/ok", "/missing", "/panic", "/late-panic"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4: %q", len(lines), buf.String())
+	}
+	tests := []struct {
+		path   string
+		level  string
+		status float64
+		size   float64
+	}{
+		{"/ok", "info", 200, 5},
+		{"/missing", "warn", 404, 19},
+		{"/panic", "error", 500, 0},
+		// The handler sent its headers before panicking, so the status
+		// stays 200, but the panic is still an error.
+		{"/late-panic", "error", 200, 7},
+	}
+	for i, tt := range tests {
+		line := lines[i]
+		if line["path"] != tt.path || line["method"] != "GET" || line["level"] != tt.level ||
+			line["status"] != tt.status || line["size"] != tt.size {
+			t.Errorf("line %d = %v, want %s at %s with status %v and size %v", i, line, tt.path, tt.level, tt.status, tt.size)
+		}
+		if _, ok := line["duration"]; !ok {
+			t.Errorf("line %d has no duration", i)
+		}
+	}
+	for _, i := range []int{2, 3} {
+		if lines[i]["panic"] != "boom" {
+			t.Errorf("line %d panic = %v, want boom", i, lines[i]["panic"])
+		}
+	}
+}
+
+func TestMiddlewareFlushAndHijack(t *testing.T) {
+	log, _ := newBufferLogger(t)
+	type result struct{ flushed, hijacked bool }
+	results := make(chan result, 1)
+	h := Middleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res result
+		defer func() { results <- res }()
+		if f, ok := w.(http.Flusher); ok {
+			_, _ = w.Write([]byte("chunk"))
+			f.Flush()
+			res.flushed = true
+		}
+		if hj, ok := w.(http.Hijacker); ok {
+			conn, _, err := hj.Hijack()
+			if err != nil {
+				t.Errorf("Hijack: %v", err)
+				return
+			}
+			res.hijacked = true
+			conn.Close()
+		}
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	if resp, err := http.Get(srv.URL); err == nil {
+		resp.Body.Close()
+	}
+	if res := <-results; !res.flushed || !res.hijacked {
+		t.Errorf("flushed = %v, hijacked = %v; want both", res.flushed, res.hijacked)
+	}
+
+	// A recorder can flush but not be hijacked.
+	rec := httptest.NewRecorder()
+	rw := &responseWriter{ResponseWriter: rec, status: http.StatusOK}
+	rw.Flush()
+	if !rec.Flushed {
+		t.Error("Flush wasn't forwarded")
+	}
+	if _, _, err := rw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Hijack error = %v, want http.ErrNotSupported", err)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"header present", "req-123"},
+		{"header absent", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t)
+			h := RequestIDMiddleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				FromContext(r.Context()).Info("handling")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if tt.header != "" && id != tt.header {
+				t.Errorf("response ID = %q, want the request's %q", id, tt.header)
+			}
+			if tt.header == "" && !uuid.MatchString(id) {
+				t.Errorf("response ID = %q, want a generated UUID", id)
+			}
+
+			lines := decodeLines(t, buf)
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want the handler's and the access line", len(lines))
+			}
+			for _, line := range lines {
+				if line["request_id"] != id {
+					t.Errorf("line %v, want request_id %q", line, id)
+				}
+			}
+			if lines[1]["msg"] != "request" {
+				t.Errorf("last line = %v, want the access line", lines[1])
+			}
+		})
+	}
+}
+
+func TestNewLoggingRoundTripper(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("hello")) })
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "oops", http.StatusBadGateway) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	client := &http.Client{Transport: NewLoggingRoundTripper(nil, log)}
+	for _, path := range []string{"/ok?token=secret-query", "/broken"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if _, err := client.Get("http://127.0.0.1:1/unreachable"); err == nil {
+		t.Fatal("request to a closed port succeeded")
+	}
+
+	if out := buf.String(); strings.Contains(out, "secret") {
+		t.Errorf("output %q leaks a credential", out)
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), buf.String())
+	}
+	tests := []struct {
+		host, path, level string
+		status, size      any
+	}{
+		{host, "/ok", "info", float64(200), float64(5)},
+		{host, "/broken", "error", float64(502), float64(5)},
+		{"127.0.0.1:1", "/unreachable", "error", nil, nil},
+	}
+	for i, tt := range tests {
+		line := lines[i]
+		if line["msg"] != "client request" || line["method"] != "GET" || line["host"] != tt.host || line["path"] != tt.path ||
+			line["level"] != tt.level || line["status"] != tt.status || line["size"] != tt.size {
+			t.Errorf("line %d = %v, want %s%s at %s with status %v and size %v", i, line, tt.host, tt.path, tt.level, tt.status, tt.size)
+		}
+		if _, ok := line["duration"]; !ok {
+			t.Errorf("line %d has no duration", i)
+		}
+	}
+	if lines[2]["error"] == nil {
+		t.Errorf("line = %v, want the transport's error", lines[2])
+	}
+}
@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// httpQueueSize bounds the batches waiting to be sent. Batches are dropped
+	// once it fills rather than blocking the caller.
+	httpQueueSize = 16
+	// httpAttempts and httpBackoff control retries of transient failures; the
+	// wait doubles after every attempt.
+	httpAttempts = 4
+	httpBackoff  = 100 * time.Millisecond
+	httpTimeout  = 10 * time.Second
+)
+
+// WithHTTPOutput adds an output that POSTs entries to endpoint as
+// newline-delimited JSON, setting headers, such as Authorization, on every
+// request. Entries are sent in batches of batchSize, or whatever has
+// accumulated every flushInterval, from a background goroutine. Network
+// errors, 429s, and 5xx responses are retried with backoff; batches that
+// still fail are dropped and the count of dropped entries is returned by the
+// next Sync. Sync also sends any partial batch and waits for delivery.
+func WithHTTPOutput(endpoint string, headers map[string]string, batchSize int, flushInterval time.Duration) loggerOpt {
+	return func(b *builder) error {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("%w: http output: %w", ErrInvalidOption, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("%w: http output %q: scheme must be http or https", ErrInvalidOption, endpoint)
+		}
+		if batchSize <= 0 || flushInterval <= 0 {
+			return fmt.Errorf("%w: http output: batch size (%d) and flush interval (%s) must be positive", ErrInvalidOption, batchSize, flushInterval)
+		}
+
+		h := make(http.Header, len(headers)+1)
+		h.Set("Content-Type", "application/x-ndjson")
+		for k, v := range headers {
+			h.Set(k, v)
+		}
+
+		return b.addWriter(func() (zapcore.WriteSyncer, error) {
+			s := newHTTPSink(endpoint, h, batchSize, flushInterval)
+			b.onShutdown(s.Close)
+			return s, nil
+		})
+	}
+}
+
+type httpBatch struct {
+	body    []byte
+	entries int64
+}
+
+// httpSink buffers lines and ships them in batches.
+type httpSink struct {
+	endpoint  string
+	headers   http.Header
+	client    *http.Client
+	batchSize int
+
+	mu      sync.Mutex
+	buf     []byte
+	entries int64
+
+	queue   chan httpBatch
+	pending inflight
+	dropped atomic.Int64
+
+	// closed is set once Close has stopped the goroutines. Batches enqueued
+	// after that are dropped rather than left waiting for them.
+	closeMu   sync.Mutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newHTTPSink(endpoint string, headers http.Header, batchSize int, flushInterval time.Duration) *httpSink {
+	s := &httpSink{
+		endpoint:  endpoint,
+		headers:   headers,
+		client:    &http.Client{Timeout: httpTimeout},
+		batchSize: batchSize,
+		queue:     make(chan httpBatch, httpQueueSize),
+		done:      make(chan struct{}),
+	}
+	go s.send()
+	go s.tick(flushInterval)
+	return s
+}
+
+func (s *httpSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	s.buf = append(s.buf, p...)
+	s.entries++
+	var batch httpBatch
+	if s.entries >= int64(s.batchSize) {
+		batch = s.take()
+	}
+	s.mu.Unlock()
+
+	s.enqueue(batch)
+	return len(p), nil
+}
+
+// Sync sends the partial batch and waits for every queued batch to be
+// delivered or dropped.
+func (s *httpSink) Sync() error {
+	s.mu.Lock()
+	batch := s.take()
+	s.mu.Unlock()
+
+	s.enqueue(batch)
+	s.pending.wait()
+
+	if n := s.dropped.Swap(0); n > 0 {
+		return fmt.Errorf("http output %s: dropped %d entries", s.endpoint, n)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	err := s.Sync()
+	s.closeOnce.Do(func() {
+		s.closeMu.Lock()
+		s.closed = true
+		close(s.done)
+		s.closeMu.Unlock()
+	})
+	return err
+}
+
+// take empties the buffer. The caller must hold s.mu.
+func (s *httpSink) take() httpBatch {
+	batch := httpBatch{body: s.buf, entries: s.entries}
+	s.buf = nil
+	s.entries = 0
+	return batch
+}
+
+func (s *httpSink) enqueue(batch httpBatch) {
+	if batch.entries == 0 {
+		return
+	}
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	if s.closed {
+		s.dropped.Add(batch.entries)
+		return
+	}
+	s.pending.add()
+	select {
+	case s.queue <- batch:
+	default:
+		s.dropped.Add(batch.entries)
+		s.pending.done()
+	}
+}
+
+func (s *httpSink) send() {
+	for {
+		select {
+		case batch := <-s.queue:
+			if err := s.post(batch.body); err != nil {
+				s.dropped.Add(batch.entries)
+			}
+			s.pending.done()
+		case <-s.done:
+			// Nothing is queued once closed is set, so the batches left
+			// can be counted off as dropped.
+			for {
+				select {
+				case batch := <-s.queue:
+					s.dropped.Add(batch.entries)
+					s.pending.done()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *httpSink) tick(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.mu.Lock()
+			batch := s.take()
+			s.mu.Unlock()
+			s.enqueue(batch)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// post delivers body, retrying transient failures.
+func (s *httpSink) post(body []byte) error {
+	var err error
+	wait := httpBackoff
+	for attempt := 1; attempt <= httpAttempts; attempt++ {
+		var retry bool
+		if retry, err = s.postOnce(body); err == nil || !retry {
+			return err
+		}
+		if attempt < httpAttempts {
+			time.Sleep(wait)
+			wait *= 2
+		}
+	}
+	return err
+}
+
+// postOnce makes a single attempt and reports whether a failure is worth
+// retrying.
+func (s *httpSink) postOnce(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header = s.headers.Clone()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("http output %s: %s", s.endpoint, resp.Status)
+	default:
+		return false, fmt.Errorf("http output %s: %s", s.endpoint, resp.Status)
+	}
+}
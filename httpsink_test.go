@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ndjsonServer records the body of every request it accepts. Requests are
+// answered with the statuses queued in fail, then with 200.
+type ndjsonServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	bodies  []string
+	headers []http.Header
+	fail    []int
+}
+
+func newNDJSONServer(t *testing.T, fail ...int) *ndjsonServer {
+	s := &ndjsonServer{fail: fail}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.fail) > 0 {
+			w.WriteHeader(s.fail[0])
+			s.fail = s.fail[1:]
+			return
+		}
+		s.bodies = append(s.bodies, string(body))
+		s.headers = append(s.headers, r.Header)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ndjsonServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func TestWithHTTPOutput(t *testing.T) {
+	srv := newNDJSONServer(t)
+	log, _ := newBufferLogger(t, WithoutSampling(),
+		WithHTTPOutput(srv.URL, map[string]string{"Authorization": "Bearer secret"}, 3, time.Hour))
+
+	for i := 0; i < 4; i++ {
+		log.Infow("shipped", "i", i)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(srv.received()) < 1 && time.Now().Before(deadline) {
+		waitABit()
+	}
+	if got := srv.received(); len(got) != 1 || strings.Count(got[0], "\n") != 3 {
+		t.Fatalf("got %q, want a full batch of 3 lines before Sync", got)
+	}
+
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	got := srv.received()
+	if len(got) != 2 || strings.Count(got[1], "\n") != 1 || !strings.Contains(got[1], `"i":3`) {
+		t.Fatalf("got %q, want Sync to send the partial batch", got)
+	}
+	h := srv.headers[0]
+	if h.Get("Authorization") != "Bearer secret" || h.Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("headers = %v, want the auth and NDJSON content type", h)
+	}
+
+	if _, err := New(TestService, WithHTTPOutput("ftp://example.com", nil, 1, time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("ftp endpoint error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithHTTPOutputRetries(t *testing.T) {
+	srv := newNDJSONServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	log, _ := newBufferLogger(t, WithHTTPOutput(srv.URL, nil, 10, time.Hour))
+	log.Info("eventually")
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := srv.received(); len(got) != 1 || !strings.Contains(got[0], "eventually") {
+		t.Errorf("got %q, want the batch delivered after retrying", got)
+	}
+
+	// Client errors aren't retried, and the entries are counted as dropped.
+	srv = newNDJSONServer(t, http.StatusBadRequest)
+	log, _ = newBufferLogger(t, WithHTTPOutput(srv.URL, nil, 10, time.Hour))
+	log.Info("rejected")
+	log.Info("rejected too")
+	if err := log.Sync(); err == nil || !strings.Contains(err.Error(), "dropped 2 entries") {
+		t.Errorf("Sync error = %v, want 2 dropped entries", err)
+	}
+	if got := srv.received(); len(got) != 0 {
+		t.Errorf("got %q, want no retry after a 400", got)
+	}
+}
+
+func TestHTTPSinkAfterClose(t *testing.T) {
+	srv := newNDJSONServer(t)
+	s := newHTTPSink(srv.URL, nil, 10, time.Hour)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	synced := make(chan error, 1)
+	go func() {
+		_, _ = s.Write([]byte("{\"msg\":\"too late\"}\n"))
+		synced <- s.Sync()
+	}()
+	select {
+	case err := <-synced:
+		if err == nil || !strings.Contains(err.Error(), "dropped 1 entries") {
+			t.Errorf("Sync error = %v, want the late entry dropped", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Sync after Close hung")
+	}
+	if got := srv.received(); len(got) != 0 {
+		t.Errorf("got %q, want nothing sent after Close", got)
+	}
+}
+
+func TestWithHTTPOutputBuildFailure(t *testing.T) {
+	srv := newNDJSONServer(t)
+	// A port nothing listens on, so the syslog output fails once the HTTP
+	// output has started.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+
+	before := runtime.NumGoroutine()
+	_, err = New(TestService, WithOutputPaths(), WithHTTPOutput(srv.URL, nil, 10, time.Hour), WithSyslog("tcp", closed, "app"))
+	if err == nil {
+		t.Fatal("New succeeded with an unreachable syslog server")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		waitABit()
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines left running after New failed, had %d", n, before)
+	}
+}
@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// kafkaBatchSize and kafkaFlushInterval bound how long lines wait before
+	// they are produced.
+	kafkaBatchSize     = 100
+	kafkaFlushInterval = time.Second
+	// kafkaQueueSize bounds the batches waiting to be produced. Batches are
+	// dropped once it fills rather than blocking the caller, and
+	// kafkaTimeout bounds each attempt to produce one.
+	kafkaQueueSize = 16
+	kafkaTimeout   = 10 * time.Second
+)
+
+// kafkaProducer is the part of kafka.Writer used by kafkaSink.
+type kafkaProducer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// newKafkaProducer is replaced in tests to avoid a running broker.
+var newKafkaProducer = func(brokers []string, topic string) kafkaProducer {
+	return &kafka.Writer{
+		Addr:     kafka.TCP(brokers...),
+		Topic:    topic,
+		Balancer: &kafka.LeastBytes{},
+	}
+}
+
+// WithKafkaOutput adds an output that produces each entry as a message to
+// topic. Lines are batched and produced from a background goroutine once
+// kafkaBatchSize of them are waiting, every kafkaFlushInterval, and on Sync.
+// Batches that can't be queued or produced are dropped, and the count of
+// dropped messages is returned by the next Sync. Shutdown flushes the last
+// batch and closes the producer.
+func WithKafkaOutput(brokers []string, topic string) loggerOpt {
+	return func(b *builder) error {
+		if len(brokers) == 0 || topic == "" {
+			return fmt.Errorf("%w: kafka output requires at least one broker and a topic", ErrInvalidOption)
+		}
+		return b.addWriter(func() (zapcore.WriteSyncer, error) {
+			s := newKafkaSink(brokers, topic)
+			b.onShutdown(s.Close)
+			return s, nil
+		})
+	}
+}
+
+func newKafkaSink(brokers []string, topic string) *kafkaSink {
+	s := &kafkaSink{
+		producer: newKafkaProducer(brokers, topic),
+		queue:    make(chan []kafka.Message, kafkaQueueSize),
+		done:     make(chan struct{}),
+	}
+	go s.run(kafkaFlushInterval)
+	return s
+}
+
+// kafkaSink batches lines and produces them with a kafkaProducer.
+type kafkaSink struct {
+	producer kafkaProducer
+
+	mu    sync.Mutex
+	batch []kafka.Message
+
+	queue   chan []kafka.Message
+	pending inflight
+	dropped atomic.Int64
+
+	// closed is set once Close has stopped the goroutine. Batches enqueued
+	// after that are dropped rather than left waiting for it.
+	closeMu   sync.Mutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *kafkaSink) Write(p []byte) (int, error) {
+	// zap reuses p once Write returns. Each message is one entry, so the
+	// line ending isn't needed.
+	line := bytes.TrimSuffix(p, []byte(zapcore.DefaultLineEnding))
+	value := make([]byte, len(line))
+	copy(value, line)
+
+	s.mu.Lock()
+	s.batch = append(s.batch, kafka.Message{Value: value})
+	var batch []kafka.Message
+	if len(s.batch) >= kafkaBatchSize {
+		batch = s.take()
+	}
+	s.mu.Unlock()
+
+	s.enqueue(batch)
+	return len(p), nil
+}
+
+// Sync queues the lines waiting in the batch and waits for every queued
+// batch to be produced or dropped.
+func (s *kafkaSink) Sync() error {
+	s.mu.Lock()
+	batch := s.take()
+	s.mu.Unlock()
+
+	s.enqueue(batch)
+	s.pending.wait()
+
+	if n := s.dropped.Swap(0); n > 0 {
+		return fmt.Errorf("kafka output: dropped %d messages", n)
+	}
+	return nil
+}
+
+func (s *kafkaSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.Sync()
+		s.closeMu.Lock()
+		s.closed = true
+		close(s.done)
+		s.closeMu.Unlock()
+		err = errors.Join(err, s.producer.Close())
+	})
+	return err
+}
+
+// take empties the batch. The caller must hold s.mu.
+func (s *kafkaSink) take() []kafka.Message {
+	batch := s.batch
+	s.batch = nil
+	return batch
+}
+
+func (s *kafkaSink) enqueue(batch []kafka.Message) {
+	if len(batch) == 0 {
+		return
+	}
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	if s.closed {
+		s.dropped.Add(int64(len(batch)))
+		return
+	}
+	s.pending.add()
+	select {
+	case s.queue <- batch:
+	default:
+		s.dropped.Add(int64(len(batch)))
+		s.pending.done()
+	}
+}
+
+func (s *kafkaSink) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case batch := <-s.queue:
+			if err := s.produce(batch); err != nil {
+				s.dropped.Add(int64(len(batch)))
+			}
+			s.pending.done()
+		case <-t.C:
+			s.mu.Lock()
+			batch := s.take()
+			s.mu.Unlock()
+			s.enqueue(batch)
+		case <-s.done:
+			// Nothing is queued once closed is set, so the batches left
+			// can be counted off as dropped.
+			for {
+				select {
+				case batch := <-s.queue:
+					s.dropped.Add(int64(len(batch)))
+					s.pending.done()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *kafkaSink) produce(batch []kafka.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaTimeout)
+	defer cancel()
+	return s.producer.WriteMessages(ctx, batch...)
+}
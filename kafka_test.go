@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeProducer records the messages produced to it. If release is set, each
+// WriteMessages call waits for it to be closed first.
+type fakeProducer struct {
+	brokers []string
+	topic   string
+	release chan struct{}
+
+	mu       sync.Mutex
+	messages []kafka.Message
+	closed   bool
+}
+
+func (p *fakeProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if p.release != nil {
+		select {
+		case <-p.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *fakeProducer) produced() []kafka.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]kafka.Message(nil), p.messages...)
+}
+
+// useFakeProducer makes the Kafka outputs built during the test produce to
+// the returned fake.
+func useFakeProducer(t *testing.T, release chan struct{}) *fakeProducer {
+	t.Helper()
+	fake := &fakeProducer{release: release}
+	orig := newKafkaProducer
+	newKafkaProducer = func(brokers []string, topic string) kafkaProducer {
+		fake.brokers, fake.topic = brokers, topic
+		return fake
+	}
+	t.Cleanup(func() { newKafkaProducer = orig })
+	return fake
+}
+
+func TestWithKafkaOutput(t *testing.T) {
+	fake := useFakeProducer(t, nil)
+	log, buf := newBufferLogger(t, WithoutSampling(), WithKafkaOutput([]string{"k1:9092", "k2:9092"}, "logs"))
+
+	log.Infow("first", "n", 1)
+	log.Infow("second", "n", 2)
+	if got := len(fake.produced()); got != 0 {
+		t.Errorf("got %d messages before Sync, want them batched", got)
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	if fake.topic != "logs" || strings.Join(fake.brokers, ",") != "k1:9092,k2:9092" {
+		t.Errorf("producer for %v / %q, want k1 and k2 / logs", fake.brokers, fake.topic)
+	}
+	msgs := fake.produced()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+	for i, want := range []string{"first", "second"} {
+		var line map[string]any
+		if err := json.Unmarshal(msgs[i].Value, &line); err != nil {
+			t.Fatalf("message %q isn't JSON: %v", msgs[i].Value, err)
+		}
+		if line["msg"] != want {
+			t.Errorf("message %d = %v, want %s", i, line, want)
+		}
+	}
+	if got := len(buf.lines()); got != 2 {
+		t.Errorf("got %d lines in the other output, want 2", got)
+	}
+
+	// A full batch is produced without waiting for Sync.
+	for i := 0; i < kafkaBatchSize; i++ {
+		log.Info("again")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(fake.produced()) < 2+kafkaBatchSize && time.Now().Before(deadline) {
+		waitABit()
+	}
+	if got := len(fake.produced()); got != 2+kafkaBatchSize {
+		t.Errorf("got %d messages, want the full batch produced", got)
+	}
+
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !fake.closed {
+		t.Error("Shutdown didn't close the producer")
+	}
+
+	if _, err := New(TestService, WithKafkaOutput(nil, "logs")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("no brokers error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithKafkaOutputDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	fake := useFakeProducer(t, release)
+	log, _ := newBufferLogger(t, WithoutSampling(), WithKafkaOutput([]string{"k1:9092"}, "logs"))
+
+	// More batches than can be queued while the broker is stuck.
+	n := kafkaBatchSize * (kafkaQueueSize + 4)
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < n; i++ {
+			log.Info("stuck")
+		}
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on a stuck producer")
+	}
+
+	close(release)
+	err := log.Sync()
+	if err == nil || !strings.Contains(err.Error(), "dropped") {
+		t.Fatalf("Sync error = %v, want the dropped messages reported", err)
+	}
+	if got := len(fake.produced()); got == 0 || got >= n {
+		t.Errorf("got %d of %d messages produced, want the queued ones only", got, n)
+	}
+	if err := log.Sync(); err != nil {
+		t.Errorf("second Sync error = %v, want the count reset", err)
+	}
+}
+
+func TestWithKafkaOutputAfterShutdown(t *testing.T) {
+	useFakeProducer(t, nil)
+	log, _ := newBufferLogger(t, WithoutSampling(), WithKafkaOutput([]string{"k1:9092"}, "logs"))
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	synced := make(chan error, 1)
+	go func() {
+		log.Info("too late")
+		synced <- log.Sync()
+	}()
+	select {
+	case err := <-synced:
+		if err == nil || !strings.Contains(err.Error(), "dropped 1 messages") {
+			t.Errorf("Sync error = %v, want the late message dropped", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Sync after Shutdown hung")
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"os"
+	"os/signal"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithSignalLevelToggle changes the logger's level when the process receives
+// a signal, for turning up verbosity in production without an HTTP endpoint:
+// up, typically syscall.SIGUSR1, lowers the level one step towards Debug and
+// down, typically syscall.SIGUSR2, raises it one step towards Error. Each
+// change is logged at Warn, with the new level in a "new_level" field, so it
+// shows unless the level is now Error. Shutdown stops listening for the
+// signals.
+func WithSignalLevelToggle(up, down os.Signal) loggerOpt {
+	return func(b *builder) error {
+		b.levelUp, b.levelDown = up, down
+		return nil
+	}
+}
+
+// toggleLevelOnSignal steps level on the signals set by WithSignalLevelToggle
+// until the returned function is called.
+func toggleLevelOnSignal(log *zap.SugaredLogger, level zap.AtomicLevel, up, down os.Signal) func() error {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	signal.Notify(sigs, up, down)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case sig := <-sigs:
+				step := 1
+				if sig == up {
+					step = -1
+				}
+				stepLevel(log, level, step)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() error {
+		signal.Stop(sigs)
+		close(done)
+		<-stopped
+		return nil
+	}
+}
+
+// stepLevel moves level by step, clamped to Debug and Error, and logs the
+// change at Warn.
+func stepLevel(log *zap.SugaredLogger, level zap.AtomicLevel, step int) {
+	old := level.Level()
+	lvl := min(max(old+zapcore.Level(step), zapcore.DebugLevel), zapcore.ErrorLevel)
+	if lvl == old {
+		return
+	}
+	level.SetLevel(lvl)
+	log.Warnw("log level changed", "new_level", levelName(lvl))
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"syscall"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithSignalLevelToggle(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLevel("debug"))
+	level := zap.NewAtomicLevelAt(TraceLevel)
+
+	steps := []struct {
+		step int
+		want zapcore.Level
+	}{
+		{-1, zapcore.DebugLevel}, // clamped up from Trace
+		{-1, zapcore.DebugLevel}, // already at Debug
+		{1, zapcore.InfoLevel},
+		{1, zapcore.WarnLevel},
+		{1, zapcore.ErrorLevel},
+		{1, zapcore.ErrorLevel}, // already at Error
+	}
+	for i, s := range steps {
+		stepLevel(log, level, s.step)
+		if got := level.Level(); got != s.want {
+			t.Fatalf("after step %d level = %v, want %v", i, got, s.want)
+		}
+	}
+
+	lines := decodeLines(t, buf)
+	wantLevels := []string{"debug", "info", "warn", "error"}
+	if len(lines) != len(wantLevels) {
+		t.Fatalf("got %d lines, want one per change: %q", len(lines), buf.String())
+	}
+	for i, want := range wantLevels {
+		if lines[i]["msg"] != "log level changed" || lines[i]["level"] != "warn" || lines[i]["new_level"] != want {
+			t.Errorf("line %d = %v, want the change to %s logged at warn", i, lines[i], want)
+		}
+	}
+
+	// A level outside the range is clamped into it rather than left alone.
+	level.SetLevel(zapcore.FatalLevel)
+	stepLevel(log, level, 1)
+	if got := level.Level(); got != zapcore.ErrorLevel {
+		t.Errorf("stepping up from fatal: level = %v, want error", got)
+	}
+
+	stop := toggleLevelOnSignal(log, level, syscall.SIGUSR1, syscall.SIGUSR2)
+	if err := stop(); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+}
@@ -0,0 +1,324 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const logfmtEncoding = "logfmt"
+
+var (
+	logfmtOnce sync.Once
+	logfmtErr  error
+
+	logfmtPool = buffer.NewPool()
+)
+
+// WithLogfmtEncoding switches the logger to logfmt output, one line of
+// space-separated key=value pairs per entry:
+//
+//	ts=2023-12-01T10:00:00.000Z level=info msg="user logged in" service=api user=bob
+//
+// Values containing spaces, quotes, equals signs, or control characters are
+// quoted. Nested objects are flattened into dotted keys and arrays are
+// written as JSON.
+func WithLogfmtEncoding() loggerOpt {
+	return func(b *builder) error {
+		logfmtOnce.Do(func() {
+			logfmtErr = zap.RegisterEncoder(logfmtEncoding, newLogfmtEncoder)
+		})
+		if logfmtErr != nil {
+			return logfmtErr
+		}
+		b.cfg.Encoding = logfmtEncoding
+		return nil
+	}
+}
+
+// logfmtEncoder implements zapcore.Encoder. Fields added with With() are
+// encoded into buf immediately; prefix holds the namespaces opened so far.
+type logfmtEncoder struct {
+	cfg    *zapcore.EncoderConfig
+	buf    *buffer.Buffer
+	prefix string
+}
+
+func newLogfmtEncoder(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	return &logfmtEncoder{cfg: &cfg, buf: logfmtPool.Get()}, nil
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{cfg: e.cfg, buf: logfmtPool.Get(), prefix: e.prefix}
+	_, _ = clone.buf.Write(e.buf.Bytes())
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{cfg: e.cfg, buf: logfmtPool.Get()}
+	cfg := e.cfg
+
+	if cfg.TimeKey != "" {
+		final.addKey(cfg.TimeKey)
+		if cfg.EncodeTime != nil {
+			final.encodeValue(func(pae zapcore.PrimitiveArrayEncoder) { cfg.EncodeTime(ent.Time, pae) })
+		} else {
+			final.appendValue(ent.Time.Format(time.RFC3339Nano))
+		}
+	}
+	if cfg.LevelKey != "" && cfg.EncodeLevel != nil {
+		final.addKey(cfg.LevelKey)
+		final.encodeValue(func(pae zapcore.PrimitiveArrayEncoder) { cfg.EncodeLevel(ent.Level, pae) })
+	}
+	if ent.LoggerName != "" && cfg.NameKey != "" {
+		final.addKey(cfg.NameKey)
+		if cfg.EncodeName != nil {
+			final.encodeValue(func(pae zapcore.PrimitiveArrayEncoder) { cfg.EncodeName(ent.LoggerName, pae) })
+		} else {
+			final.appendValue(ent.LoggerName)
+		}
+	}
+	if ent.Caller.Defined {
+		if cfg.CallerKey != "" && cfg.EncodeCaller != nil {
+			final.addKey(cfg.CallerKey)
+			final.encodeValue(func(pae zapcore.PrimitiveArrayEncoder) { cfg.EncodeCaller(ent.Caller, pae) })
+		}
+		if cfg.FunctionKey != "" {
+			final.addKey(cfg.FunctionKey)
+			final.appendValue(ent.Caller.Function)
+		}
+	}
+	if cfg.MessageKey != "" {
+		final.addKey(cfg.MessageKey)
+		final.appendValue(ent.Message)
+	}
+
+	if e.buf.Len() > 0 {
+		if final.buf.Len() > 0 {
+			final.buf.AppendByte(' ')
+		}
+		_, _ = final.buf.Write(e.buf.Bytes())
+	}
+
+	final.prefix = e.prefix
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.prefix = ""
+
+	if ent.Stack != "" && cfg.StacktraceKey != "" {
+		final.AddString(cfg.StacktraceKey, ent.Stack)
+	}
+
+	if cfg.LineEnding != "" {
+		final.buf.AppendString(cfg.LineEnding)
+	} else {
+		final.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+	return final.buf, nil
+}
+
+// addKey starts a new pair, writing the separator, namespace prefix, and key.
+func (e *logfmtEncoder) addKey(key string) {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+	e.appendKey(e.prefix)
+	e.appendKey(key)
+	e.buf.AppendByte('=')
+}
+
+// appendKey writes key with characters that would break the pair replaced.
+func (e *logfmtEncoder) appendKey(key string) {
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			r = '_'
+		}
+		if r < utf8.RuneSelf {
+			e.buf.AppendByte(byte(r))
+		} else {
+			_, _ = e.buf.WriteString(string(r))
+		}
+	}
+}
+
+// appendValue writes s, quoting it when it would otherwise be ambiguous.
+func (e *logfmtEncoder) appendValue(s string) {
+	if needsQuoting(s) {
+		e.buf.AppendString(strconv.Quote(s))
+		return
+	}
+	e.buf.AppendString(s)
+}
+
+// encodeValue writes the output of one of the EncoderConfig's encoders.
+func (e *logfmtEncoder) encodeValue(encode func(zapcore.PrimitiveArrayEncoder)) {
+	encode(logfmtValue{e})
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *logfmtEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, v); err != nil {
+		return err
+	}
+	return e.AddReflected(key, m.Fields[key])
+}
+
+func (e *logfmtEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	prefix := e.prefix
+	e.prefix += key + "."
+	err := v.MarshalLogObject(e)
+	e.prefix = prefix
+	return err
+}
+
+func (e *logfmtEncoder) AddBinary(key string, v []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *logfmtEncoder) AddByteString(key string, v []byte) { e.AddString(key, string(v)) }
+
+func (e *logfmtEncoder) AddBool(key string, v bool) {
+	e.addKey(key)
+	e.buf.AppendBool(v)
+}
+
+func (e *logfmtEncoder) AddComplex128(key string, v complex128) {
+	e.addKey(key)
+	e.buf.AppendString(strconv.FormatComplex(v, 'g', -1, 128))
+}
+
+func (e *logfmtEncoder) AddComplex64(key string, v complex64) {
+	e.addKey(key)
+	e.buf.AppendString(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+
+func (e *logfmtEncoder) AddDuration(key string, v time.Duration) {
+	e.addKey(key)
+	if e.cfg.EncodeDuration != nil {
+		e.encodeValue(func(pae zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeDuration(v, pae) })
+		return
+	}
+	e.buf.AppendInt(int64(v))
+}
+
+func (e *logfmtEncoder) AddFloat64(key string, v float64) {
+	e.addKey(key)
+	logfmtValue{e}.AppendFloat64(v)
+}
+
+func (e *logfmtEncoder) AddFloat32(key string, v float32) {
+	e.addKey(key)
+	logfmtValue{e}.AppendFloat32(v)
+}
+
+func (e *logfmtEncoder) AddInt(key string, v int)     { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder) AddInt32(key string, v int32) { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder) AddInt16(key string, v int16) { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder) AddInt8(key string, v int8)   { e.AddInt64(key, int64(v)) }
+
+func (e *logfmtEncoder) AddInt64(key string, v int64) {
+	e.addKey(key)
+	e.buf.AppendInt(v)
+}
+
+func (e *logfmtEncoder) AddString(key, v string) {
+	e.addKey(key)
+	e.appendValue(v)
+}
+
+func (e *logfmtEncoder) AddTime(key string, v time.Time) {
+	e.addKey(key)
+	if e.cfg.EncodeTime != nil {
+		e.encodeValue(func(pae zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(v, pae) })
+		return
+	}
+	e.buf.AppendInt(v.UnixNano())
+}
+
+func (e *logfmtEncoder) AddUint(key string, v uint)       { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUint32(key string, v uint32)   { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUint16(key string, v uint16)   { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUint8(key string, v uint8)     { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUintptr(key string, v uintptr) { e.AddString(key, fmt.Sprintf("%#x", v)) }
+
+func (e *logfmtEncoder) AddUint64(key string, v uint64) {
+	e.addKey(key)
+	e.buf.AppendUint(v)
+}
+
+func (e *logfmtEncoder) AddReflected(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.AddString(key, string(b))
+	return nil
+}
+
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.prefix += key + "."
+}
+
+// logfmtValue lets the EncoderConfig's time, level, duration, caller, and name
+// encoders write a single value into a logfmtEncoder.
+type logfmtValue struct {
+	e *logfmtEncoder
+}
+
+func (v logfmtValue) AppendBool(b bool)         { v.e.buf.AppendBool(b) }
+func (v logfmtValue) AppendByteString(b []byte) { v.e.appendValue(string(b)) }
+func (v logfmtValue) AppendComplex128(c complex128) {
+	v.e.buf.AppendString(strconv.FormatComplex(c, 'g', -1, 128))
+}
+func (v logfmtValue) AppendComplex64(c complex64) {
+	v.e.buf.AppendString(strconv.FormatComplex(complex128(c), 'g', -1, 64))
+}
+func (v logfmtValue) AppendInt(i int)         { v.e.buf.AppendInt(int64(i)) }
+func (v logfmtValue) AppendInt64(i int64)     { v.e.buf.AppendInt(i) }
+func (v logfmtValue) AppendInt32(i int32)     { v.e.buf.AppendInt(int64(i)) }
+func (v logfmtValue) AppendInt16(i int16)     { v.e.buf.AppendInt(int64(i)) }
+func (v logfmtValue) AppendInt8(i int8)       { v.e.buf.AppendInt(int64(i)) }
+func (v logfmtValue) AppendString(s string)   { v.e.appendValue(s) }
+func (v logfmtValue) AppendUint(u uint)       { v.e.buf.AppendUint(uint64(u)) }
+func (v logfmtValue) AppendUint64(u uint64)   { v.e.buf.AppendUint(u) }
+func (v logfmtValue) AppendUint32(u uint32)   { v.e.buf.AppendUint(uint64(u)) }
+func (v logfmtValue) AppendUint16(u uint16)   { v.e.buf.AppendUint(uint64(u)) }
+func (v logfmtValue) AppendUint8(u uint8)     { v.e.buf.AppendUint(uint64(u)) }
+func (v logfmtValue) AppendUintptr(u uintptr) { v.e.buf.AppendString(fmt.Sprintf("%#x", u)) }
+func (v logfmtValue) AppendFloat32(f float32) { v.appendFloat(float64(f), 32) }
+func (v logfmtValue) AppendFloat64(f float64) { v.appendFloat(f, 64) }
+
+func (v logfmtValue) appendFloat(f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		v.e.buf.AppendString("NaN")
+	case math.IsInf(f, 1):
+		v.e.buf.AppendString("+Inf")
+	case math.IsInf(f, -1):
+		v.e.buf.AppendString("-Inf")
+	default:
+		v.e.buf.AppendFloat(f, bitSize)
+	}
+}
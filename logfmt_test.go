@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWithLogfmtEncoding(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLogfmtEncoding())
+	log.Desugar().Info("user logged in",
+		zap.String("user", "bob"),
+		zap.String("note", "two words"),
+		zap.String("expr", "a=b"),
+		zap.String("empty", ""),
+		zap.Int("attempt", 2),
+		zap.Strings("roles", []string{"admin", "dev"}),
+		zap.Namespace("req"),
+		zap.String("id", "x1"),
+	)
+
+	lines := buf.lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(lines), lines)
+	}
+	line := lines[0]
+	if !strings.HasPrefix(line, "ts=") {
+		t.Errorf("line %q doesn't start with ts=", line)
+	}
+	for _, want := range []string{
+		` level=info `,
+		` msg="user logged in" `,
+		` service=test `,
+		` user=bob `,
+		` note="two words" `,
+		` expr="a=b" `,
+		` empty="" `,
+		` attempt=2 `,
+		` roles="[\"admin\",\"dev\"]" `,
+		` req.id=x1`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("line %q is missing %q", line, want)
+		}
+	}
+}
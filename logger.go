@@ -3,14 +3,27 @@ package logger
 import (
 	"fmt"
 	"log"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// TraceLevel sits below zapcore.DebugLevel for output too verbose for Debug.
+// Enable it with `WithLevel("trace")` and log with `Trace()` or `Tracef()`.
+const TraceLevel = zapcore.DebugLevel - 1
+
+// serviceKey is the initial field holding the service name passed to New.
+const serviceKey = "service"
+
 var (
 	logLevels = map[string]zapcore.Level{
+		"TRACE":  TraceLevel,
 		"DEBUG":  zapcore.DebugLevel,
 		"INFO":   zapcore.InfoLevel,
 		"WARN":   zapcore.WarnLevel,
@@ -21,106 +34,1016 @@ var (
 	}
 )
 
-type loggerOpt func(*zap.Config) error
+type loggerOpt func(*builder) error
+
+// builder collects the zap.Config along with the pieces a config can't
+// express.
+type builder struct {
+	cfg zap.Config
+	// cores wrap the core built from cfg, innermost first, and zapOpts are
+	// passed on to Build after them.
+	cores   []func(zapcore.Core) zapcore.Core
+	zapOpts []zap.Option
+	// tees are extra outputs, such as Sentry or syslog, teed with the core
+	// built from cfg inside every wrapper in cores, so redaction and the
+	// other rewrites apply to them whatever order the options came in. They
+	// are started when the logger is built.
+	tees []func() (zapcore.Core, error)
+	// routes, when set, replace the core built from cfg's output paths, and
+	// buffer wraps the outputs. fallbackPaths are opened instead of cfg's
+	// output paths if those fail to open.
+	routes        []route
+	buffer        func(zapcore.WriteSyncer) zapcore.WriteSyncer
+	fallbackPaths []string
+	// extraPaths are the outputs added by options such as WithOutputWriter,
+	// written alongside cfg's output paths, or every route.
+	extraPaths []string
+	// closers are called by Shutdown. syncInterval, when set, is how often the
+	// built logger is synced in the background.
+	closers      []func() error
+	syncInterval time.Duration
+	// levelUp and levelDown step the level while the logger runs.
+	levelUp, levelDown os.Signal
+	// utc converts timestamps to UTC before the configured time encoder runs,
+	// color, when set, overrides whether console levels are colored, and
+	// fullCaller and jsonDurationString override the caller and duration
+	// encoders set by any other option.
+	utc                bool
+	color              *bool
+	fullCaller         bool
+	jsonDurationString bool
+	// orderedFields follow the initial fields, in the order they were given.
+	orderedFields []zap.Field
+	// funcFieldKeys are the keys of the fields added by core wrappers, such
+	// as WithFieldFromFunc.
+	funcFieldKeys []string
+	// namespace nests every field added after the initial fields.
+	namespace string
+	// levelAliases are extra level names accepted by the options given after
+	// WithLevelAliases.
+	levelAliases map[string]zapcore.Level
+	// createDirs creates the parent directories of file outputs,
+	// reopenSignal, when set, reopens them, and fileMode, when set, is the
+	// mode they're created with.
+	createDirs   bool
+	reopenSignal os.Signal
+	fileMode     os.FileMode
+	// sampleBelow, when set, exempts entries at or above it from sampling, and
+	// samplingHook is told of every sampling decision.
+	sampleBelow  *zapcore.Level
+	samplingHook func(zapcore.Entry, zapcore.SamplingDecision)
+	// tail, when set, streams entries to TailHandler clients.
+	tail *tailHub
+}
 
 // New constructs a Sugared Logger that writes to stdout and
 // provides human-readable timestamps.
 func New(service string, opts ...loggerOpt) (*zap.SugaredLogger, error) {
+	log, _, err := NewWithLevel(service, opts...)
+	return log, err
+}
+
+// Must returns log, panicking if err is non-nil. It wraps `New()` for programs
+// that can't run without a logger: `log := logger.Must(logger.New("myapp"))`.
+// The constructors name the service in their errors, so the panic does too.
+func Must(log *zap.SugaredLogger, err error) *zap.SugaredLogger {
+	if err != nil {
+		panic(fmt.Sprintf("logger: building logger: %v", err))
+	}
+	return log
+}
+
+// NewWithLevel behaves like `New()` but also returns the zap.AtomicLevel used
+// by the logger, allowing the level to be changed at runtime:
+// `level.SetLevel(zapcore.DebugLevel)`.
+func NewWithLevel(service string, opts ...loggerOpt) (*zap.SugaredLogger, zap.AtomicLevel, error) {
+	if strings.TrimSpace(service) == "" {
+		return nil, zap.AtomicLevel{}, ErrEmptyService
+	}
+
 	config := zap.NewProductionConfig()
 
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	config.DisableStacktrace = true
-	config.InitialFields = map[string]any{
-		"service": service,
-	}
 	config.OutputPaths = []string{"stdout"}
 	config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
 
+	return newLogger(service, config, opts)
+}
+
+// NewDevelopment constructs a Sugared Logger suited to working locally: it
+// starts from zap's development config, giving console output at DebugLevel
+// with stack traces on Warn and above, and panics on DPanic. Levels are
+// colored when the first output path is a terminal. Every option accepted by
+// `New()` applies.
+func NewDevelopment(service string, opts ...loggerOpt) (*zap.SugaredLogger, error) {
+	if strings.TrimSpace(service) == "" {
+		return nil, ErrEmptyService
+	}
+
+	config := zap.NewDevelopmentConfig()
+
+	config.OutputPaths = []string{"stdout"}
+
+	log, _, err := newLogger(service, config, opts)
+	return log, err
+}
+
+// NewNop returns a logger that discards everything, for libraries to use
+// when they're given a nil logger.
+func NewNop() *zap.SugaredLogger {
+	return zap.NewNop().Sugar()
+}
+
+// newLogger adds the service field to config and builds it with opts applied.
+// Errors name the service, since Must has no other way to report it.
+func newLogger(service string, config zap.Config, opts []loggerOpt) (*zap.SugaredLogger, zap.AtomicLevel, error) {
+	config.InitialFields = map[string]any{
+		serviceKey: service,
+	}
+
+	b := &builder{cfg: config}
 	for _, opt := range opts {
-		if err := opt(&config); err != nil {
-			return nil, err
+		if err := opt(b); err != nil {
+			b.close()
+			return nil, zap.AtomicLevel{}, fmt.Errorf("service %q: %w", service, err)
 		}
 	}
 
-	log, err := config.Build(zap.WithCaller(true))
+	log, err := b.build()
 	if err != nil {
+		b.close()
+		return nil, zap.AtomicLevel{}, fmt.Errorf("service %q: %w", service, err)
+	}
+
+	sugar := log.Sugar()
+	if b.syncInterval > 0 {
+		b.onShutdown(syncEvery(sugar, b.syncInterval))
+	}
+	if b.levelUp != nil || b.levelDown != nil {
+		b.onShutdown(toggleLevelOnSignal(sugar, b.cfg.Level, b.levelUp, b.levelDown))
+	}
+	if b.tail != nil {
+		tails.Store(sugar, b.tail)
+		b.onShutdown(func() error {
+			tails.Delete(sugar)
+			return b.tail.close()
+		})
+	}
+	if len(b.closers) > 0 {
+		closers.Store(sugar, b.closers)
+	}
+	registry.Store(sugar, struct{}{})
+	return sugar, b.cfg.Level, nil
+}
+
+// build constructs the logger from the collected configuration. Sampling and
+// the initial fields are applied outside of the core wrappers so the wrappers
+// only see entries the sampler lets through, and see the initial fields too.
+// The configuration is checked before anything is created or opened; what is
+// opened after that is registered with onShutdown, so newLogger can close it
+// if the build fails.
+func (b *builder) build() (*zap.Logger, error) {
+	if err := b.checkFieldKeys(); err != nil {
 		return nil, err
 	}
+	if b.routes != nil && len(b.extraPaths) > 0 {
+		b.routes = append(b.routes, route{min: TraceLevel, max: zapcore.FatalLevel, paths: b.extraPaths})
+	} else if b.fallbackPaths == nil {
+		// With a fallback, the extra paths are opened on their own, so they
+		// are kept whichever of the primary and fallback paths is used.
+		b.cfg.OutputPaths = append(b.cfg.OutputPaths, b.extraPaths...)
+	}
+	if b.createDirs {
+		if err := b.makeDirs(); err != nil {
+			return nil, err
+		}
+	}
+	if b.reopenSignal != nil || b.fileMode != 0 {
+		outputs := []*[]string{&b.cfg.OutputPaths, &b.fallbackPaths}
+		for i := range b.routes {
+			outputs = append(outputs, &b.routes[i].paths)
+		}
+		if err := b.openFiles(outputs...); err != nil {
+			return nil, err
+		}
+	}
+	color := b.color
+	if color == nil && b.cfg.Encoding == "console" && sameFunc(b.cfg.EncoderConfig.EncodeLevel, zapcore.CapitalLevelEncoder) {
+		tty := len(b.cfg.OutputPaths) > 0 && isTerminal(b.cfg.OutputPaths[0])
+		color = &tty
+	}
+	if color != nil && b.cfg.Encoding == "console" {
+		b.cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		if *color {
+			b.cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+	}
+	if b.cfg.EncoderConfig.EncodeLevel != nil {
+		b.cfg.EncoderConfig.EncodeLevel = traceLevelEncoder(b.cfg.EncoderConfig.EncodeLevel)
+	}
+	if b.utc && b.cfg.EncoderConfig.EncodeTime != nil {
+		b.cfg.EncoderConfig.EncodeTime = utcTimeEncoder(b.cfg.EncoderConfig.EncodeTime)
+	}
+	if b.fullCaller {
+		b.cfg.EncoderConfig.EncodeCaller = zapcore.FullCallerEncoder
+	}
+	if b.jsonDurationString && b.cfg.Encoding != "console" && b.cfg.Encoding != logfmtEncoding {
+		b.cfg.EncoderConfig.EncodeDuration = zapcore.StringDurationEncoder
+	}
+
+	cfg := b.cfg
+	sampling := cfg.Sampling
+	fields := initialFields(cfg.InitialFields)
+	cfg.Sampling = nil
+	cfg.InitialFields = nil
 
-	return log.Sugar(), nil
+	opts := []zap.Option{zap.WithCaller(!cfg.DisableCaller)}
+	if b.routes != nil {
+		routed, err := b.routeCore()
+		if err != nil {
+			return nil, err
+		}
+		cfg.OutputPaths = nil
+		opts = append(opts, zap.WrapCore(func(zapcore.Core) zapcore.Core { return routed }))
+	} else if b.buffer != nil || b.fallbackPaths != nil {
+		ws, err := b.openOutputs(cfg.OutputPaths)
+		if err != nil && b.fallbackPaths != nil {
+			fmt.Fprintf(os.Stderr, "logger: %v; writing to %s instead\n", err, strings.Join(b.fallbackPaths, ", "))
+			ws, err = b.openOutputs(b.fallbackPaths)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b.fallbackPaths != nil && len(b.extraPaths) > 0 {
+			extra, err := b.openOutputs(b.extraPaths)
+			if err != nil {
+				return nil, err
+			}
+			ws = zapcore.NewMultiWriteSyncer(ws, extra)
+		}
+		path, err := registerWriter(ws)
+		if err != nil {
+			return nil, err
+		}
+		cfg.OutputPaths = []string{path}
+	}
+	if len(b.tees) > 0 {
+		tees := make([]zapcore.Core, 0, len(b.tees))
+		for _, tee := range b.tees {
+			core, err := tee()
+			if err != nil {
+				return nil, err
+			}
+			tees = append(tees, core)
+		}
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(append([]zapcore.Core{core}, tees...)...)
+		}))
+	}
+	for _, wrap := range b.cores {
+		opts = append(opts, zap.WrapCore(wrap))
+	}
+	if sampling != nil {
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			var samplerOpts []zapcore.SamplerOption
+			if sampling.Hook != nil {
+				samplerOpts = append(samplerOpts, zapcore.SamplerHook(sampling.Hook))
+			}
+			if b.samplingHook != nil {
+				samplerOpts = append(samplerOpts, zapcore.SamplerHook(b.samplingHook))
+			}
+			sampled := zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter, samplerOpts...)
+			if b.sampleBelow != nil {
+				return &levelSampler{Core: core, sampled: sampled, below: *b.sampleBelow}
+			}
+			return sampled
+		}))
+	}
+	opts = append(opts, b.zapOpts...)
+	opts = append(opts, zap.Fields(fields...), zap.Fields(b.orderedFields...))
+	if b.namespace != "" {
+		opts = append(opts, zap.Fields(zap.Namespace(b.namespace)))
+	}
+
+	return cfg.Build(opts...)
+}
+
+// newEncoder builds the encoder configured in b.cfg, for cores that write
+// somewhere other than the output paths. Encodings registered with zap
+// outside of this package can't be looked up, so those fall back to JSON.
+func (b *builder) newEncoder() zapcore.Encoder {
+	cfg := b.cfg.EncoderConfig
+	switch b.cfg.Encoding {
+	case "console":
+		return zapcore.NewConsoleEncoder(cfg)
+	case logfmtEncoding:
+		enc, _ := newLogfmtEncoder(cfg)
+		return enc
+	case gelfEncoding:
+		enc, _ := newGELFEncoder(cfg)
+		return enc
+	case ecsEncoding:
+		enc, _ := newECSEncoder(cfg)
+		return enc
+	case prettyJSONEncoding:
+		enc, _ := newPrettyJSONEncoder(cfg)
+		return enc
+	default:
+		return zapcore.NewJSONEncoder(cfg)
+	}
+}
+
+// checkFieldKeys rejects fields whose keys collide with the keys the encoder
+// writes itself, which would repeat the key in each entry. It runs once the
+// options are applied, since any of them may change the encoder's keys, and
+// before build creates directories or opens files.
+func (b *builder) checkFieldKeys() error {
+	ec := b.cfg.EncoderConfig
+	reserved := make(map[string]bool, 6)
+	for _, k := range []string{ec.LevelKey, ec.TimeKey, ec.MessageKey, ec.CallerKey, ec.NameKey, ec.StacktraceKey} {
+		if k != "" && k != zapcore.OmitKey {
+			reserved[k] = true
+		}
+	}
+
+	keys := make([]string, 0, len(b.cfg.InitialFields)+len(b.orderedFields)+len(b.funcFieldKeys))
+	for k := range b.cfg.InitialFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, f := range b.orderedFields {
+		keys = append(keys, f.Key)
+	}
+	keys = append(keys, b.funcFieldKeys...)
+	for _, k := range keys {
+		if reserved[k] {
+			return fmt.Errorf("%w %q: used by the encoder", ErrReservedField, k)
+		}
+	}
+	return nil
+}
+
+// initialFields converts the InitialFields map into fields sorted by key, the
+// same order zap.Config uses.
+func initialFields(m map[string]any) []zap.Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.Any(k, m[k]))
+	}
+	return fields
+}
+
+// Trace logs the message at TraceLevel, formatting args like the Sugared
+// Logger's Debug.
+func Trace(log *zap.SugaredLogger, args ...any) {
+	l := log.Desugar()
+	if !l.Core().Enabled(TraceLevel) {
+		return
+	}
+	l.WithOptions(zap.AddCallerSkip(1)).Log(TraceLevel, fmt.Sprint(args...))
+}
+
+// Tracef logs a templated message at TraceLevel.
+func Tracef(log *zap.SugaredLogger, template string, args ...any) {
+	l := log.Desugar()
+	if !l.Core().Enabled(TraceLevel) {
+		return
+	}
+	l.WithOptions(zap.AddCallerSkip(1)).Log(TraceLevel, fmt.Sprintf(template, args...))
+}
+
+// traceLevelEncoder prints "TRACE" for TraceLevel, which zap's encoders don't
+// know about, and defers every other level to enc. Encoders that already
+// handle TraceLevel, such as the one used by WithGELF, are left alone.
+func traceLevelEncoder(enc zapcore.LevelEncoder) zapcore.LevelEncoder {
+	if encodesLevel(enc, TraceLevel) {
+		return enc
+	}
+	return func(l zapcore.Level, pae zapcore.PrimitiveArrayEncoder) {
+		if l == TraceLevel {
+			pae.AppendString("TRACE")
+			return
+		}
+		enc(l, pae)
+	}
+}
+
+// encodesLevel reports whether enc has its own representation for lvl rather
+// than appending nothing or zap's "Level(n)" fallback.
+func encodesLevel(enc zapcore.LevelEncoder, lvl zapcore.Level) bool {
+	m := zapcore.NewMapObjectEncoder()
+	_ = m.AddArray("level", zapcore.ArrayMarshalerFunc(func(ae zapcore.ArrayEncoder) error {
+		enc(lvl, ae)
+		return nil
+	}))
+
+	vals, _ := m.Fields["level"].([]any)
+	if len(vals) == 0 {
+		return false
+	}
+	s, ok := vals[0].(string)
+	return !ok || !strings.Contains(strings.ToLower(s), strings.ToLower(lvl.String()))
+}
+
+// Desugar returns the structured *zap.Logger behind log, with the same
+// configuration and fields. The Sugared Logger's key-value methods, such as
+// Infow, allocate for their variadic arguments on every call; in hot paths,
+// log through the structured logger with typed fields instead, which avoids
+// that allocation:
+//
+//	fast := logger.Desugar(log)
+//	fast.Info("request", zap.String("path", path), zap.Int("status", status))
+func Desugar(log *zap.SugaredLogger) *zap.Logger {
+	return log.Desugar()
+}
+
+// Named returns a copy of log for a subcomponent, sharing its configuration
+// and fields. name is appended to log's name, separated by a period, and
+// written under the "logger" key.
+func Named(log *zap.SugaredLogger, name string) *zap.SugaredLogger {
+	return log.Desugar().Named(name).Sugar()
+}
+
+// ReplaceGlobals installs log as zap's global logger, returned by zap.L and
+// zap.S, so libraries logging through them go through the same pipeline. It
+// returns a function that restores the previous globals.
+func ReplaceGlobals(log *zap.SugaredLogger) func() {
+	return zap.ReplaceGlobals(log.Desugar())
 }
 
 func NewStdLogger(log *zap.SugaredLogger) *log.Logger {
 	return zap.NewStdLog(log.Desugar())
 }
 
+// RedirectStdLog sends output from the standard library's global logger, used
+// by packages calling log.Print and friends, to log at Info. It returns a
+// function that restores the previous output.
+func RedirectStdLog(log *zap.SugaredLogger) func() {
+	return zap.RedirectStdLog(log.Desugar())
+}
+
+// NewStdLoggerAt behaves like NewStdLogger but writes at level, which accepts
+// the same names as WithLevel other than "trace", instead of Info.
+func NewStdLoggerAt(log *zap.SugaredLogger, level string) (*log.Logger, error) {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return zap.NewStdLogAt(log.Desugar(), lvl)
+}
+
+// NoOp returns an option that does nothing, for code that must pass an option
+// even when it has none to give.
+func NoOp() loggerOpt {
+	return func(*builder) error {
+		return nil
+	}
+}
+
+// When applies opt only if cond is true, so options can be chosen inline:
+//
+//	logger.New(svc, logger.When(debug, logger.WithLevel("debug")))
+func When(cond bool, opt loggerOpt) loggerOpt {
+	if !cond || opt == nil {
+		return NoOp()
+	}
+	return opt
+}
+
+// Group combines opts into one option that applies them in order, stopping at
+// the first error, so a set of options can be shared as a preset:
+//
+//	var ProdPreset = logger.Group(logger.WithGCPMapping(), logger.WithSampling(100, 100))
+func Group(opts ...loggerOpt) loggerOpt {
+	return func(b *builder) error {
+		for _, opt := range opts {
+			if err := opt(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithLevel sets the minimum enabled level. The level is updated in place so
+// the zap.AtomicLevel returned by `NewWithLevel()` reflects it.
 func WithLevel(level string) loggerOpt {
-	return func(cfg *zap.Config) error {
-		key := strings.ToUpper(level)
-		lvl, ok := logLevels[key]
+	return func(b *builder) error {
+		lvl, err := b.parseLevel(level)
+		if err != nil {
+			return err
+		}
+		b.cfg.Level.SetLevel(lvl)
+		return nil
+	}
+}
+
+// WithLevelFromEnv sets the minimum enabled level from the environment
+// variable envVar, which accepts the same level names as WithLevel. The level
+// is left alone if envVar is unset or empty.
+func WithLevelFromEnv(envVar string) loggerOpt {
+	return func(b *builder) error {
+		level := os.Getenv(envVar)
+		if level == "" {
+			return nil
+		}
+		if err := WithLevel(level)(b); err != nil {
+			return fmt.Errorf("%s: %w", envVar, err)
+		}
+		return nil
+	}
+}
+
+// WithZapOptions passes opts through to zap when the logger is built, for
+// settings that zap only exposes as a zap.Option.
+func WithZapOptions(opts ...zap.Option) loggerOpt {
+	return func(b *builder) error {
+		b.zapOpts = append(b.zapOpts, opts...)
+		return nil
+	}
+}
+
+// WithClock sets the source of entry timestamps, letting tests fix the time
+// written to each entry.
+func WithClock(clock zapcore.Clock) loggerOpt {
+	return WithZapOptions(zap.WithClock(clock))
+}
+
+// timeEncoders maps the formats accepted by WithTimeEncoder to zap's encoders.
+var timeEncoders = map[string]zapcore.TimeEncoder{
+	"iso8601":     zapcore.ISO8601TimeEncoder,
+	"rfc3339":     zapcore.RFC3339TimeEncoder,
+	"rfc3339nano": zapcore.RFC3339NanoTimeEncoder,
+	"epoch":       zapcore.EpochTimeEncoder,
+	"epochmillis": zapcore.EpochMillisTimeEncoder,
+	"epochnanos":  zapcore.EpochNanosTimeEncoder,
+}
+
+// WithTimeEncoder sets the timestamp format, which `New()` sets to "iso8601".
+// The other formats are "rfc3339", "rfc3339nano", and "epoch", "epochmillis",
+// and "epochnanos" for seconds, milliseconds, and nanoseconds since the Unix
+// epoch.
+func WithTimeEncoder(format string) loggerOpt {
+	return func(b *builder) error {
+		enc, ok := timeEncoders[strings.ToLower(format)]
 		if !ok {
-			return fmt.Errorf("unknown log level %q", level)
+			return fmt.Errorf("%w: time format %q", ErrUnknownFormat, format)
+		}
+		b.cfg.EncoderConfig.EncodeTime = enc
+		return nil
+	}
+}
+
+// WithTimeLayout formats timestamps with a Go time layout, such as
+// "2006-01-02 15:04:05.000", for consumers none of WithTimeEncoder's formats
+// suit.
+func WithTimeLayout(layout string) loggerOpt {
+	return func(b *builder) error {
+		if layout == "" {
+			return fmt.Errorf("%w: time layout must not be empty", ErrInvalidOption)
+		}
+		b.cfg.EncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(layout)
+		return nil
+	}
+}
+
+// durationEncoders maps the formats accepted by WithDurationEncoder to zap's
+// encoders.
+var durationEncoders = map[string]zapcore.DurationEncoder{
+	"seconds": zapcore.SecondsDurationEncoder,
+	"millis":  zapcore.MillisDurationEncoder,
+	"nanos":   zapcore.NanosDurationEncoder,
+	"string":  zapcore.StringDurationEncoder,
+}
+
+// WithDurationEncoder sets how zap.Duration fields are written: "seconds"
+// (the default) and "millis" as floats, "nanos" as an integer, or "string" as
+// formatted by time.Duration, such as "1.5s".
+func WithDurationEncoder(format string) loggerOpt {
+	return func(b *builder) error {
+		enc, ok := durationEncoders[strings.ToLower(format)]
+		if !ok {
+			return fmt.Errorf("%w: duration format %q", ErrUnknownFormat, format)
+		}
+		b.cfg.EncoderConfig.EncodeDuration = enc
+		return nil
+	}
+}
+
+// WithJSONDurationAsString writes zap.Duration fields as strings formatted
+// by time.Duration, such as "1.25s", when the output is JSON, leaving console
+// and logfmt output as configured. It takes effect whatever the order of
+// options, so it also overrides mappings such as WithGCPMapping.
+func WithJSONDurationAsString() loggerOpt {
+	return func(b *builder) error {
+		b.jsonDurationString = true
+		return nil
+	}
+}
+
+// WithUTC writes timestamps in UTC rather than the local time zone, whichever
+// time encoder the other options choose.
+func WithUTC() loggerOpt {
+	return func(b *builder) error {
+		b.utc = true
+		return nil
+	}
+}
+
+// utcTimeEncoder converts the time to UTC before passing it to enc.
+func utcTimeEncoder(enc zapcore.TimeEncoder) zapcore.TimeEncoder {
+	return func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
+		enc(t.UTC(), pae)
+	}
+}
+
+// WithHooks calls each hook with every entry written, after level filtering
+// and sampling. Hooks run synchronously on the logging goroutine, so keep
+// them cheap.
+func WithHooks(hooks ...func(zapcore.Entry) error) loggerOpt {
+	return WithZapOptions(zap.Hooks(hooks...))
+}
+
+// WithOnFatal sets what happens after a Fatal entry is written, in place of
+// calling os.Exit(1). zapcore.WriteThenPanic and zapcore.WriteThenGoexit let
+// deferred functions, such as ones calling Shutdown, run before the program
+// ends. zap ignores zapcore.WriteThenNoop here, since Fatal must not return.
+func WithOnFatal(action zapcore.CheckWriteAction) loggerOpt {
+	return WithZapOptions(zap.WithFatalHook(action))
+}
+
+// WithStacktrace re-enables stack traces, which `New()` disables, for entries
+// at or above level. It accepts the same level names as WithLevel.
+func WithStacktrace(level string) loggerOpt {
+	return func(b *builder) error {
+		lvl, err := b.parseLevel(level)
+		if err != nil {
+			return err
+		}
+		b.cfg.DisableStacktrace = false
+		return WithZapOptions(zap.AddStacktrace(lvl))(b)
+	}
+}
+
+// WithCallerSkip increases the number of stack frames skipped when recording
+// the caller. Use one per helper function wrapping the logger so the caller
+// field points at the code calling the helper rather than the helper itself.
+func WithCallerSkip(n int) loggerOpt {
+	return WithZapOptions(zap.AddCallerSkip(n))
+}
+
+// WithDisableCaller stops annotating entries with the calling file and line,
+// saving the runtime.Caller lookup on every entry.
+func WithDisableCaller() loggerOpt {
+	return func(b *builder) error {
+		b.cfg.DisableCaller = true
+		return nil
+	}
+}
+
+// WithFullCaller reports the caller with the full path of its source file
+// rather than the package and file name alone, for tools that open the file.
+// It takes effect whatever the order of options, so it also overrides the
+// caller encoding of mappings such as WithGCPMapping.
+func WithFullCaller() loggerOpt {
+	return func(b *builder) error {
+		b.fullCaller = true
+		return nil
+	}
+}
+
+// WithLevelAliases teaches the options that follow it, such as WithLevel and
+// WithLevelFromEnv, extra level names. aliases maps each new name to one of
+// the level names accepted by WithLevel, e.g. {"verbose": "debug"}. Names are
+// case-insensitive and can't replace the built-in ones.
+func WithLevelAliases(aliases map[string]string) loggerOpt {
+	return func(b *builder) error {
+		merged := make(map[string]zapcore.Level, len(b.levelAliases)+len(aliases))
+		for name, lvl := range b.levelAliases {
+			merged[name] = lvl
+		}
+		for alias, level := range aliases {
+			name := strings.ToUpper(alias)
+			if _, ok := logLevels[name]; ok {
+				return fmt.Errorf("%w: level alias %q is a built-in level", ErrInvalidOption, alias)
+			}
+			lvl, err := parseLevel(level)
+			if err != nil {
+				return fmt.Errorf("level alias %q: %w", alias, err)
+			}
+			merged[name] = lvl
 		}
-		cfg.Level = zap.NewAtomicLevelAt(lvl)
+		b.levelAliases = merged
+		return nil
+	}
+}
+
+// parseLevel looks up a level name, also accepting the names added by
+// WithLevelAliases.
+func (b *builder) parseLevel(level string) (zapcore.Level, error) {
+	if lvl, ok := b.levelAliases[strings.ToUpper(level)]; ok {
+		return lvl, nil
+	}
+	return parseLevel(level)
+}
+
+// parseLevel looks up a case-insensitive level name in logLevels.
+func parseLevel(level string) (zapcore.Level, error) {
+	lvl, ok := logLevels[strings.ToUpper(level)]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownLevel, level)
+	}
+	return lvl, nil
+}
+
+// WithEncoding switches the encoder used by the logger. Accepted values are
+// "json" (the default) and "console". Console output uses capitalized level
+// names, colored when the first output path is a terminal once every option
+// is applied.
+func WithEncoding(encoding string) loggerOpt {
+	return func(b *builder) error {
+		cfg := &b.cfg
+		enc := strings.ToLower(encoding)
+		switch enc {
+		case "json":
+		case "console":
+			cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		default:
+			return fmt.Errorf("%w: encoding %q", ErrUnknownFormat, encoding)
+		}
+		cfg.Encoding = enc
+		return nil
+	}
+}
+
+// WithColor turns colored level names in console output on or off. Without
+// it, levels are colored only when the first output path is a terminal. It has
+// no effect on other encodings.
+func WithColor(enabled bool) loggerOpt {
+	return func(b *builder) error {
+		b.color = &enabled
+		return nil
+	}
+}
+
+// WithConsoleSeparator sets the separator written between the parts of each
+// console entry, a tab by default. It has no effect on other encodings.
+func WithConsoleSeparator(sep string) loggerOpt {
+	return func(b *builder) error {
+		b.cfg.EncoderConfig.ConsoleSeparator = sep
+		return nil
+	}
+}
+
+// WithEncoderConfig calls fn with the encoder config as set by the options
+// before it, for changes no option covers, such as renaming a single key:
+//
+//	WithEncoderConfig(func(cfg *zapcore.EncoderConfig) { cfg.TimeKey = "@timestamp" })
+func WithEncoderConfig(fn func(*zapcore.EncoderConfig)) loggerOpt {
+	return func(b *builder) error {
+		fn(&b.cfg.EncoderConfig)
 		return nil
 	}
 }
 
 // WithZapConfig will overwrite the standard configurations provided by `New()`
 // any loggerOpt provided AFTER this function when calling `New()` will
-// continue to modify this provided config.
+// continue to modify this provided config. The initial fields of config are
+// added to those set so far, such as "service", which is always kept. The
+// logger gets its own AtomicLevel, starting at config's level or Info if it
+// has none, so changing the logger's level leaves config's untouched.
 func WithZapConfig(config zap.Config) loggerOpt {
-	return func(cfg *zap.Config) error {
-		cfg = &config
+	return func(b *builder) error {
+		lvl := zapcore.InfoLevel
+		if config.Level != (zap.AtomicLevel{}) {
+			lvl = config.Level.Level()
+		}
+		config.Level = zap.NewAtomicLevelAt(lvl)
+
+		fields := make(map[string]any, len(config.InitialFields)+len(b.cfg.InitialFields))
+		for k, v := range config.InitialFields {
+			fields[k] = v
+		}
+		for k, v := range b.cfg.InitialFields {
+			fields[k] = v
+		}
+		config.InitialFields = fields
+
+		b.cfg = config
 		return nil
 	}
 }
 
+// WithFields adds fields to every entry, alongside the "service" field set by
+// `New()`. Multiple calls accumulate, with later values winning for repeated
+// keys. Overwriting "service" is an error, as is using a key the encoder
+// writes itself, such as "message" under WithGCPMapping, whatever the order of
+// the options.
+func WithFields(fields map[string]any) loggerOpt {
+	return func(b *builder) error {
+		merged := make(map[string]any, len(b.cfg.InitialFields)+len(fields))
+		for k, v := range b.cfg.InitialFields {
+			merged[k] = v
+		}
+		for k, v := range fields {
+			if k == serviceKey {
+				return fmt.Errorf("%w %q: set by New and can't be overwritten", ErrReservedField, k)
+			}
+			merged[k] = v
+		}
+		b.cfg.InitialFields = merged
+		return nil
+	}
+}
+
+// WithOrderedFields adds fields to every entry from key-value pairs, written
+// in the order given after the fields from WithFields, which are sorted by
+// key. Keys must be strings, and "service" can't be used.
+func WithOrderedFields(pairs ...any) loggerOpt {
+	return func(b *builder) error {
+		if len(pairs)%2 != 0 {
+			return fmt.Errorf("%w: ordered fields need a value for key %v", ErrInvalidOption, pairs[len(pairs)-1])
+		}
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return fmt.Errorf("%w: ordered field key %v is not a string", ErrInvalidOption, pairs[i])
+			}
+			if key == serviceKey {
+				return fmt.Errorf("%w %q: set by New and can't be overwritten", ErrReservedField, key)
+			}
+			b.orderedFields = append(b.orderedFields, zap.Any(key, pairs[i+1]))
+		}
+		return nil
+	}
+}
+
+// WithNamespace nests every field logged, or added with With, under name, so
+// fields from different subsystems can't collide. The initial fields, such as
+// "service", stay at the top level.
+func WithNamespace(name string) loggerOpt {
+	return func(b *builder) error {
+		if name == "" {
+			return fmt.Errorf("%w: namespace must not be empty", ErrInvalidOption)
+		}
+		b.namespace = name
+		return nil
+	}
+}
+
+// WithServiceVersion adds a "version" field to every entry.
+func WithServiceVersion(v string) loggerOpt {
+	return withRequiredField("version", v)
+}
+
+// WithEnvironment adds an "env" field to every entry, e.g. "staging".
+func WithEnvironment(env string) loggerOpt {
+	return withRequiredField("env", env)
+}
+
+// WithHostInfo adds the machine's hostname as "host" and the process ID as
+// "pid" to every entry. The host is "unknown" if the hostname can't be read.
+func WithHostInfo() loggerOpt {
+	return func(b *builder) error {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "unknown"
+		}
+		return WithFields(map[string]any{"host": host, "pid": os.Getpid()})(b)
+	}
+}
+
+// WithBuildInfo adds fields describing the running binary from the build
+// information Go embeds in it: "go_version", the main module's "version", and
+// the "vcs_revision", "vcs_time", and "vcs_modified" recorded from version
+// control. Values the binary wasn't built with are skipped, so only
+// "go_version" is certain. A "version" from WithServiceVersion given after
+// this option takes precedence.
+func WithBuildInfo() loggerOpt {
+	return func(b *builder) error {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return nil
+		}
+
+		fields := map[string]any{"go_version": info.GoVersion}
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			fields["version"] = v
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				if s.Value != "" {
+					fields[strings.Replace(s.Key, ".", "_", 1)] = s.Value
+				}
+			}
+		}
+		return WithFields(fields)(b)
+	}
+}
+
+// withRequiredField adds a single initial field, rejecting blank values.
+func withRequiredField(key, value string) loggerOpt {
+	return func(b *builder) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%w: field %q must not be empty", ErrInvalidOption, key)
+		}
+		return WithFields(map[string]any{key: value})(b)
+	}
+}
+
 // WithOutputPaths overrides the default OutputPaths of os.Stdout. Multiple
 // files, URLs, can also be included in this function. For example:
 // `WithOutputPaths("stdout", "/var/logs/myapp.log")` will print to a file and
-// the standard output
+// the standard output. Files are created if missing and zap opens them with
+// O_APPEND, so they're always appended to, never truncated, and restarting a
+// service keeps its earlier logs. Outputs added by options such as
+// WithOutputWriter or WithRotatingFile are kept, whichever order the options
+// come in.
 func WithOutputPaths(outputPaths ...string) loggerOpt {
-	return func(cfg *zap.Config) error {
-		cfg.OutputPaths = outputPaths
-		return nil
-	}
-}
-
-// WithGCPMapping rewrites the zap config to utilize encoding values to conform
-// to the standards used on Google Cloud logging systems. For more information
-// refer to the following Github Issue/Discussion
-// https://github.com/uber-go/zap/discussions/1110#discussioncomment-2955566
-func WithGCPMapping() loggerOpt {
-	return func(cfg *zap.Config) error {
-		cfg.EncoderConfig.TimeKey = "time"
-		cfg.EncoderConfig.LevelKey = "severity"
-		cfg.EncoderConfig.NameKey = "logger"
-		cfg.EncoderConfig.CallerKey = "caller"
-		cfg.EncoderConfig.MessageKey = "message"
-		cfg.EncoderConfig.StacktraceKey = "stacktrace"
-		cfg.EncoderConfig.LineEnding = zapcore.DefaultLineEnding
-		cfg.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
-		cfg.EncoderConfig.EncodeDuration = zapcore.MillisDurationEncoder
-		cfg.EncoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
-		cfg.EncoderConfig.EncodeLevel = func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
-			switch l {
-			case zapcore.DebugLevel:
-				enc.AppendString("DEBUG")
-			case zapcore.InfoLevel:
-				enc.AppendString("INFO")
-			case zapcore.WarnLevel:
-				enc.AppendString("WARNING")
-			case zapcore.ErrorLevel:
-				enc.AppendString("ERROR")
-			case zapcore.DPanicLevel:
-				enc.AppendString("CRITICAL")
-			case zapcore.PanicLevel:
-				enc.AppendString("ALERT")
-			case zapcore.FatalLevel:
-				enc.AppendString("EMERGENCY")
-			}
+	return func(b *builder) error {
+		b.cfg.OutputPaths = outputPaths
+		return nil
+	}
+}
+
+// WithOutputPathsFallback behaves like WithOutputPaths but, rather than
+// failing, writes to fallback if any of the primary paths can't be opened,
+// such as a file in a missing directory. The reason is printed to stderr.
+// Outputs added by options such as WithOutputWriter are kept either way.
+func WithOutputPathsFallback(primary, fallback []string) loggerOpt {
+	return func(b *builder) error {
+		if len(fallback) == 0 {
+			return fmt.Errorf("%w: output paths fallback must not be empty", ErrInvalidOption)
 		}
+		b.cfg.OutputPaths = primary
+		b.fallbackPaths = fallback
+		return nil
+	}
+}
+
+// WithErrorOutputPaths sets where zap reports its own internal errors, such as
+// a sink failing to write. The production default is stderr.
+func WithErrorOutputPaths(paths ...string) loggerOpt {
+	return func(b *builder) error {
+		b.cfg.ErrorOutputPaths = paths
 		return nil
 	}
 }
+
+// WithSampling tunes zap's sampler: within each second the first `initial`
+// entries with the same level and message are logged, then only every
+// `thereafter`th one. The production default is 100 and 100.
+func WithSampling(initial, thereafter int) loggerOpt {
+	return func(b *builder) error {
+		if initial < 0 || thereafter < 0 {
+			return fmt.Errorf("%w: sampling initial (%d) and thereafter (%d) must not be negative", ErrInvalidOption, initial, thereafter)
+		}
+		b.cfg.Sampling = &zap.SamplingConfig{
+			Initial:    initial,
+			Thereafter: thereafter,
+		}
+		return nil
+	}
+}
+
+// WithoutSampling disables sampling so every entry is logged.
+func WithoutSampling() loggerOpt {
+	return func(b *builder) error {
+		b.cfg.Sampling = nil
+		return nil
+	}
+}
+
+// sameFunc reports whether a and b are the same function.
+func sameFunc(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Kind() == reflect.Func && vb.Kind() == reflect.Func && va.Pointer() == vb.Pointer()
+}
+
+// isTerminal reports whether the output path refers to a standard stream that
+// is attached to a terminal.
+func isTerminal(path string) bool {
+	var f *os.File
+	switch path {
+	case "stdout":
+		f = os.Stdout
+	case "stderr":
+		f = os.Stderr
+	default:
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,1063 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// syncBuffer is a bytes.Buffer that is safe to write from the background
+// goroutines some options start.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// lines returns the non-empty lines written so far.
+func (b *syncBuffer) lines() []string {
+	var out []string
+	for _, l := range strings.Split(b.String(), "\n") {
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// newBufferLogger builds a logger for service "test" that writes only to the
+// returned buffer, with opts applied after that, and shuts it down when the
+// test ends.
+func newBufferLogger(t *testing.T, opts ...loggerOpt) (*zap.SugaredLogger, *syncBuffer) {
+	t.Helper()
+	buf := &syncBuffer{}
+	opts = append([]loggerOpt{WithOutputPaths(), WithOutputWriter(buf)}, opts...)
+	log, err := New(TestService, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = Shutdown(log) })
+	return log, buf
+}
+
+// decodeLines parses each line written to buf as a JSON object.
+func decodeLines(t *testing.T, buf *syncBuffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, l := range buf.lines() {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("line %q isn't JSON: %v", l, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestWithZapConfig(t *testing.T) {
+	config := zap.NewDevelopmentConfig()
+	config.OutputPaths = nil
+	config.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	config.InitialFields = map[string]any{"region": "eu"}
+
+	log, buf := newBufferLogger(t, WithZapConfig(config), WithLevel("debug"))
+	log.Debug("hello")
+
+	lines := buf.lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(lines), lines)
+	}
+	if strings.HasPrefix(lines[0], "{") || !strings.Contains(lines[0], "DEBUG") {
+		t.Errorf("line %q isn't console encoded at debug", lines[0])
+	}
+	for _, want := range []string{`"service": "test"`, `"region": "eu"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("line %q is missing %s", lines[0], want)
+		}
+	}
+	if config.Level.Level() != zap.ErrorLevel {
+		t.Errorf("config's level changed to %s", config.Level.Level())
+	}
+}
+
+func TestWithEncoding(t *testing.T) {
+	log, buf := newBufferLogger(t, WithEncoding("json"))
+	log.Info("hello")
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["msg"] != "hello" {
+		t.Errorf("json output = %q", buf.String())
+	}
+
+	log, buf = newBufferLogger(t, WithEncoding("Console"))
+	log.Infow("hello", "k", "v")
+	out := buf.String()
+	if strings.HasPrefix(out, "{") || !strings.Contains(out, "\tINFO\t") || !strings.Contains(out, `"k": "v"`) {
+		t.Errorf("console output = %q", out)
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("console output to a buffer is colored: %q", out)
+	}
+
+	if _, err := New(TestService, WithEncoding("xml")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("WithEncoding(xml) error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestNewWithLevel(t *testing.T) {
+	buf := &syncBuffer{}
+	log, level, err := NewWithLevel(TestService, WithOutputPaths(), WithOutputWriter(buf), WithLevel("info"))
+	if err != nil {
+		t.Fatalf("NewWithLevel: %v", err)
+	}
+	defer Shutdown(log)
+
+	log.Debug("hidden")
+	level.SetLevel(zap.DebugLevel)
+	log.Debug("shown")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 || lines[0]["msg"] != "shown" {
+		t.Errorf("got %q, want only the line logged after SetLevel", buf.String())
+	}
+}
+
+// waitABit pauses for output written by a background goroutine.
+func waitABit() {
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestWithSampling(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  loggerOpt
+		want int
+	}{
+		{"sampled", WithSampling(2, 3), 5},
+		{"unsampled", WithoutSampling(), 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t, tt.opt)
+			for i := 0; i < 11; i++ {
+				log.Info("again")
+			}
+			if got := len(buf.lines()); got != tt.want {
+				t.Errorf("got %d lines, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(TestService, WithSampling(-1, 0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("negative sampling error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	Trace(log, "hidden")
+	Tracef(log, "hidden %d", 1)
+	if out := buf.String(); out != "" {
+		t.Errorf("trace lines written at info: %q", out)
+	}
+
+	log, buf = newBufferLogger(t, WithLevel("trace"))
+	Trace(log, "shown")
+	Tracef(log, "shown %d", 2)
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, msg := range []string{"shown", "shown 2"} {
+		if lines[i]["level"] != "TRACE" || lines[i]["msg"] != msg {
+			t.Errorf("line %d = %v, want %q at TRACE", i, lines[i], msg)
+		}
+		if caller, _ := lines[i]["caller"].(string); !strings.Contains(caller, "logger_test.go") {
+			t.Errorf("caller = %q, want this file", caller)
+		}
+	}
+}
+
+func TestWithStacktrace(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	log.Error("no trace")
+
+	log, buf2 := newBufferLogger(t, WithStacktrace("error"))
+	log.Warn("below")
+	log.Error("failed")
+
+	if line := decodeLines(t, buf)[0]; line["stacktrace"] != nil {
+		t.Errorf("New records stack traces by default: %v", line)
+	}
+	lines := decodeLines(t, buf2)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if _, ok := lines[0]["stacktrace"]; ok {
+		t.Error("Warn line has a stack trace")
+	}
+	if st, _ := lines[1]["stacktrace"].(string); !strings.Contains(st, "TestWithStacktrace") {
+		t.Errorf("Error line stacktrace = %q, want this test in it", st)
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLevel("WARN"))
+	log.Info("hidden")
+	log.Warn("shown")
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["msg"] != "shown" {
+		t.Errorf("got %q, want only the warning", buf.String())
+	}
+
+	if _, err := New(TestService, WithLevel("loud")); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("WithLevel(loud) error = %v, want ErrUnknownLevel", err)
+	}
+}
+
+func TestWithOutputPaths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for _, msg := range []string{"first", "second"} {
+		log, err := New(TestService, WithOutputPaths(path))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		log.Info(msg)
+		if err := Shutdown(log); err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "first") || !strings.Contains(lines[1], "second") {
+		t.Errorf("file = %q, want both runs appended", data)
+	}
+}
+
+func TestWithZapOptions(t *testing.T) {
+	var seen []string
+	log, _ := newBufferLogger(t, WithZapOptions(zap.Hooks(func(ent zapcore.Entry) error {
+		seen = append(seen, ent.Message)
+		return nil
+	})))
+	log.Info("hooked")
+	if len(seen) != 1 || seen[0] != "hooked" {
+		t.Errorf("hook saw %q, want [hooked]", seen)
+	}
+}
+
+// logVia is a helper wrapping the logger, as TestWithCallerSkip needs.
+func logVia(log *zap.SugaredLogger, msg string) {
+	log.Info(msg)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	observe := WithZapOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, core)
+	}))
+	log, _ := newBufferLogger(t, observe, WithCallerSkip(1))
+
+	_, _, line, _ := runtime.Caller(0)
+	logVia(log, "through a helper")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	caller := entries[0].Caller
+	if !strings.HasSuffix(caller.File, "logger_test.go") || caller.Line != line+1 {
+		t.Errorf("caller = %s, want logger_test.go:%d", caller.TrimmedPath(), line+1)
+	}
+}
+
+func TestWithDisableCaller(t *testing.T) {
+	log, buf := newBufferLogger(t, WithDisableCaller())
+	log.Info("hello")
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	if _, ok := lines[0]["caller"]; ok {
+		t.Errorf("line = %v, want no caller", lines[0])
+	}
+}
+
+func TestWithFullCaller(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	tests := []struct {
+		name string
+		opts []loggerOpt
+	}{
+		{"default", []loggerOpt{WithFullCaller()}},
+		{"before GCP mapping", []loggerOpt{WithFullCaller(), WithGCPMapping()}},
+		{"after GCP mapping", []loggerOpt{WithGCPMapping(), WithFullCaller()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t, tt.opts...)
+			log.Info("hello")
+			caller, _ := decodeLines(t, buf)[0]["caller"].(string)
+			if !strings.HasPrefix(caller, file+":") {
+				t.Errorf("caller = %q, want the full path %s", caller, file)
+			}
+		})
+	}
+}
+
+func BenchmarkCaller(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		opts []loggerOpt
+	}{
+		{"with", nil},
+		{"without", []loggerOpt{WithDisableCaller()}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			opts := append([]loggerOpt{WithOutputPaths(), WithOutputWriter(io.Discard), WithoutSampling()}, bb.opts...)
+			log, err := New(TestService, opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer Shutdown(log)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				log.Infow("request", "path", "/", "status", 200)
+			}
+		})
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	log, buf := newBufferLogger(t,
+		WithFields(map[string]any{"region": "eu", "zone": "a"}),
+		WithFields(map[string]any{"zone": "b", "build": 7}),
+	)
+	log.Info("hello")
+
+	line := decodeLines(t, buf)[0]
+	want := map[string]any{"service": TestService, "region": "eu", "zone": "b", "build": float64(7)}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+
+	if _, err := New(TestService, WithFields(map[string]any{"service": "other"})); !errors.Is(err, ErrReservedField) {
+		t.Errorf("overwriting service error = %v, want ErrReservedField", err)
+	}
+}
+
+func TestFieldsCollidingWithEncoderKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []loggerOpt
+		key  string
+	}{
+		{"mapping after", []loggerOpt{WithFields(map[string]any{"message": "x"}), WithGCPMapping()}, `"message"`},
+		{"mapping before", []loggerOpt{WithGCPMapping(), WithFields(map[string]any{"severity": "x"})}, `"severity"`},
+		{"ordered", []loggerOpt{WithOrderedFields("msg", "x")}, `"msg"`},
+		{"from func", []loggerOpt{WithFieldFromFunc("ts", func() any { return 1 })}, `"ts"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(TestService, tt.opts...)
+			if !errors.Is(err, ErrReservedField) || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("error = %v, want ErrReservedField for %s", err, tt.key)
+			}
+		})
+	}
+
+	// The fields are checked before anything is created or opened.
+	dir := filepath.Join(t.TempDir(), "logs")
+	_, err := New(TestService, WithCreateDirs(), WithOutputPaths(filepath.Join(dir, "app.log")),
+		WithFields(map[string]any{"message": "x"}), WithGCPMapping())
+	if !errors.Is(err, ErrReservedField) {
+		t.Fatalf("error = %v, want ErrReservedField", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s was created despite the error", dir)
+	}
+
+	// Without a mapping, GCP's keys are ordinary fields.
+	log, buf := newBufferLogger(t, WithFields(map[string]any{"message": "x"}))
+	log.Info("hello")
+	if line := decodeLines(t, buf)[0]; line["message"] != "x" {
+		t.Errorf("line = %v, want the message field", line)
+	}
+}
+
+func TestWithServiceVersionAndEnvironment(t *testing.T) {
+	log, buf := newBufferLogger(t, WithEnvironment("staging"), WithFields(map[string]any{"region": "eu"}), WithServiceVersion("1.2.3"))
+	log.Info("hello")
+
+	line := decodeLines(t, buf)[0]
+	if line["version"] != "1.2.3" || line["env"] != "staging" || line["region"] != "eu" {
+		t.Errorf("line = %v, want version, env and region", line)
+	}
+
+	for name, opt := range map[string]loggerOpt{"version": WithServiceVersion(""), "env": WithEnvironment(" ")} {
+		if _, err := New(TestService, opt); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("empty %s error = %v, want ErrInvalidOption", name, err)
+		}
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk on fire") }
+
+func TestWithErrorOutputPaths(t *testing.T) {
+	errPath := filepath.Join(t.TempDir(), "zap-errors.log")
+	log, err := New(TestService, WithOutputPaths(), WithOutputWriter(failingWriter{}), WithErrorOutputPaths(errPath))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	log.Info("lost")
+	_ = Shutdown(log)
+
+	data, err := os.ReadFile(errPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "disk on fire") {
+		t.Errorf("error output = %q, want the failed write reported", data)
+	}
+}
+
+func TestWithLevelFromEnv(t *testing.T) {
+	const env = "LOGGER_TEST_LEVEL"
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    zapcore.Level
+		wantErr error
+	}{
+		{"unset", "", false, zap.InfoLevel, nil},
+		{"empty", "", true, zap.InfoLevel, nil},
+		{"valid", "debug", true, zap.DebugLevel, nil},
+		{"upper case", "ERROR", true, zap.ErrorLevel, nil},
+		{"invalid", "loud", true, 0, ErrUnknownLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				t.Setenv(env, tt.value)
+			}
+			log, level, err := NewWithLevel(TestService, WithOutputPaths(), WithLevelFromEnv(env))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), env) {
+					t.Errorf("error = %v, want %v naming %s", err, tt.wantErr, env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWithLevel: %v", err)
+			}
+			defer Shutdown(log)
+			if level.Level() != tt.want {
+				t.Errorf("level = %s, want %s", level.Level(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWhen(t *testing.T) {
+	failing := func(*builder) error { return errors.New("applied") }
+	for _, debug := range []bool{false, true} {
+		log, buf := newBufferLogger(t, When(debug, WithLevel("debug")), When(false, failing), NoOp())
+		log.Debug("details")
+		log.Info("hello")
+
+		want := 1
+		if debug {
+			want = 2
+		}
+		if lines := decodeLines(t, buf); len(lines) != want {
+			t.Errorf("When(%v, debug) logged %d lines, want %d", debug, len(lines), want)
+		}
+	}
+
+	if _, err := New(TestService, When(true, failing)); err == nil || !strings.Contains(err.Error(), "applied") {
+		t.Errorf("When(true) error = %v, want the option's error", err)
+	}
+	if _, err := New(TestService, When(true, nil)); err != nil {
+		t.Errorf("When(true, nil) error = %v, want none", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	preset := Group(WithLevel("debug"), WithFields(map[string]any{"region": "eu"}), WithNamespace("app"))
+	log, buf := newBufferLogger(t, preset)
+	log.Debugw("details", "k", "v")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want the debug entry", len(lines))
+	}
+	if app, _ := lines[0]["app"].(map[string]any); lines[0]["region"] != "eu" || app["k"] != "v" {
+		t.Errorf("line = %v, want every option in the group applied", lines[0])
+	}
+
+	applied := false
+	rest := func(*builder) error { applied = true; return nil }
+	if _, err := New(TestService, Group(WithLevel("debug"), WithLevel("loud"), rest)); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("error = %v, want ErrUnknownLevel", err)
+	}
+	if applied {
+		t.Error("options after the failing one were applied")
+	}
+}
+
+func TestMust(t *testing.T) {
+	log := Must(New(TestService, WithOutputPaths()))
+	if log == nil {
+		t.Fatal("Must returned nil for a logger built without error")
+	}
+	_ = Shutdown(log)
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, `service "billing"`) || !strings.Contains(msg, "loud") {
+			t.Errorf("panic = %q, want the service and the cause", msg)
+		}
+	}()
+	Must(New("billing", WithLevel("loud")))
+	t.Error("Must didn't panic")
+}
+
+func TestNewEmptyService(t *testing.T) {
+	for _, service := range []string{"", "  \t"} {
+		if _, err := New(service); !errors.Is(err, ErrEmptyService) {
+			t.Errorf("New(%q) error = %v, want ErrEmptyService", service, err)
+		}
+	}
+	log, err := New("api", WithOutputPaths())
+	if err != nil {
+		t.Fatalf("New(api): %v", err)
+	}
+	_ = Shutdown(log)
+}
+
+// fixedClock always reports the same time.
+type fixedClock struct{ t time.Time }
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+func (c fixedClock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+func TestWithClock(t *testing.T) {
+	clock := fixedClock{time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)}
+	log, buf := newBufferLogger(t, WithClock(clock))
+	log.Info("first")
+	log.Info("second")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, line := range lines {
+		if line["ts"] != "2023-12-01T10:00:00.000Z" {
+			t.Errorf("line %d ts = %v, want the clock's time", i, line["ts"])
+		}
+	}
+}
+
+func TestWithUTC(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	clock := WithClock(fixedClock{time.Date(2023, 12, 1, 5, 0, 0, 0, est)})
+
+	tests := []struct {
+		name string
+		opts []loggerOpt
+		key  string
+		want string
+	}{
+		{"local", []loggerOpt{clock}, "ts", "2023-12-01T05:00:00.000-0500"},
+		{"iso8601", []loggerOpt{clock, WithUTC()}, "ts", "2023-12-01T10:00:00.000Z"},
+		{"rfc3339", []loggerOpt{WithUTC(), clock, WithTimeEncoder("rfc3339")}, "ts", "2023-12-01T10:00:00Z"},
+		{"gcp", []loggerOpt{WithUTC(), clock, WithGCPMapping()}, "time", "2023-12-01T10:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t, tt.opts...)
+			log.Info("hello")
+			if got := decodeLines(t, buf)[0][tt.key]; got != tt.want {
+				t.Errorf("%s = %v, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTimeEncoder(t *testing.T) {
+	clock := WithClock(fixedClock{time.Date(2023, 12, 1, 10, 0, 0, 500_000_000, time.UTC)})
+	tests := []struct {
+		format string
+		want   any
+	}{
+		{"iso8601", "2023-12-01T10:00:00.500Z"},
+		{"RFC3339", "2023-12-01T10:00:00Z"},
+		{"rfc3339nano", "2023-12-01T10:00:00.5Z"},
+		{"epoch", 1701424800.5},
+		{"epochmillis", 1701424800500.0},
+		{"epochnanos", 1701424800500000000.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			log, buf := newBufferLogger(t, clock, WithTimeEncoder(tt.format))
+			log.Info("hello")
+			if got := decodeLines(t, buf)[0]["ts"]; got != tt.want {
+				t.Errorf("ts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(TestService, WithTimeEncoder("sundial")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unknown format error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestWithTimeLayout(t *testing.T) {
+	const layout = "2006-01-02 15:04:05.000"
+	now := time.Date(2023, 12, 1, 10, 0, 0, 500_000_000, time.UTC)
+	log, buf := newBufferLogger(t, WithClock(fixedClock{now}), WithUTC(), WithTimeLayout(layout))
+	log.Info("hello")
+
+	ts, _ := decodeLines(t, buf)[0]["ts"].(string)
+	got, err := time.Parse(layout, ts)
+	if err != nil {
+		t.Fatalf("ts %q doesn't match the layout: %v", ts, err)
+	}
+	if !got.Equal(now) {
+		t.Errorf("ts = %v, want %v", got, now)
+	}
+
+	if _, err := New(TestService, WithTimeLayout("")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("empty layout error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithDurationEncoder(t *testing.T) {
+	tests := []struct {
+		format string
+		want   any
+	}{
+		{"seconds", 1.5},
+		{"Millis", 1500.0},
+		{"nanos", 1.5e9},
+		{"string", "1.5s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			log, buf := newBufferLogger(t, WithDurationEncoder(tt.format))
+			log.Infow("took", "elapsed", 1500*time.Millisecond)
+			if got := decodeLines(t, buf)[0]["elapsed"]; got != tt.want {
+				t.Errorf("elapsed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(TestService, WithDurationEncoder("fortnights")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unknown format error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestWithJSONDurationAsString(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []loggerOpt
+	}{
+		{"default", []loggerOpt{WithJSONDurationAsString()}},
+		{"before GCP mapping", []loggerOpt{WithJSONDurationAsString(), WithGCPMapping()}},
+		{"after GCP mapping", []loggerOpt{WithGCPMapping(), WithJSONDurationAsString()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t, tt.opts...)
+			log.Infow("took", "elapsed", 1250*time.Millisecond)
+			if got := decodeLines(t, buf)[0]["elapsed"]; got != "1.25s" {
+				t.Errorf("elapsed = %v, want 1.25s", got)
+			}
+		})
+	}
+
+	// Console output keeps the configured encoder.
+	log, buf := newBufferLogger(t, WithEncoding("console"), WithJSONDurationAsString())
+	log.Infow("took", "elapsed", 1250*time.Millisecond)
+	if out := buf.String(); !strings.Contains(out, `"elapsed": 1.25}`) {
+		t.Errorf("console output = %q, want the duration in seconds", out)
+	}
+}
+
+func TestNewDevelopment(t *testing.T) {
+	buf := &syncBuffer{}
+	log, err := NewDevelopment(TestService, WithOutputPaths(), WithOutputWriter(buf))
+	if err != nil {
+		t.Fatalf("NewDevelopment: %v", err)
+	}
+	defer Shutdown(log)
+
+	log.Debug("visible")
+	log.Warn("careful")
+	lines := buf.lines()
+	if len(lines) < 2 {
+		t.Fatalf("got %q, want the debug and warn lines", buf.String())
+	}
+	if strings.HasPrefix(lines[0], "{") || !strings.Contains(lines[0], "\tDEBUG\t") {
+		t.Errorf("line %q isn't console encoded at debug", lines[0])
+	}
+	warn := lines[1]
+	if !strings.Contains(warn, "\tWARN\t") || !strings.Contains(warn, "logger_test.go:") || !strings.Contains(warn, `"service": "test"`) {
+		t.Errorf("warn line %q is missing the level, caller or service", warn)
+	}
+	if !strings.Contains(buf.String(), "TestNewDevelopment") {
+		t.Error("warn line has no stack trace")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("DPanic didn't panic")
+		}
+	}()
+	log.DPanic("broken invariant")
+}
+
+func TestWithColor(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []loggerOpt
+		want bool
+	}{
+		{"on", []loggerOpt{WithEncoding("console"), WithColor(true)}, true},
+		{"off", []loggerOpt{WithColor(false), WithEncoding("console")}, false},
+		{"detected", []loggerOpt{WithEncoding("console")}, false},
+		{"json", []loggerOpt{WithColor(true)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t, tt.opts...)
+			log.Warn("careful")
+			if got := strings.Contains(buf.String(), "\x1b["); got != tt.want {
+				t.Errorf("output %q colored = %v, want %v", buf.String(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithConsoleSeparator(t *testing.T) {
+	log, buf := newBufferLogger(t, WithEncoding("console"), WithConsoleSeparator(" | "))
+	log.Infow("hello", "k", "v")
+	parts := strings.Split(strings.TrimSpace(buf.String()), " | ")
+	if len(parts) != 5 || parts[1] != "INFO" || parts[3] != "hello" {
+		t.Errorf("line %q, want time | INFO | caller | hello | fields", buf.String())
+	}
+}
+
+func TestNewNop(t *testing.T) {
+	log := NewNop()
+	log.With("k", "v").Errorw("ignored", "err", errors.New("boom"))
+	Trace(log, "ignored")
+	if log.Desugar().Core().Enabled(zap.FatalLevel) {
+		t.Error("the no-op logger has levels enabled")
+	}
+	if err := log.Sync(); err != nil {
+		t.Errorf("Sync: %v", err)
+	}
+}
+
+func TestWithHooks(t *testing.T) {
+	var seen []zapcore.Entry
+	log, _ := newBufferLogger(t, WithHooks(func(ent zapcore.Entry) error {
+		seen = append(seen, ent)
+		return nil
+	}))
+	log.Debug("filtered")
+	log.Info("seen")
+	if len(seen) != 1 || seen[0].Message != "seen" || seen[0].Level != zap.InfoLevel {
+		t.Errorf("hook saw %v, want only the Info entry", seen)
+	}
+}
+
+func TestWithOnFatal(t *testing.T) {
+	log, buf := newBufferLogger(t, WithOnFatal(zapcore.WriteThenPanic))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Fatal didn't panic")
+			}
+		}()
+		log.Fatal("cannot continue")
+	}()
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["level"] != "fatal" {
+		t.Errorf("got %q, want the fatal entry written before the panic", buf.String())
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	log, buf := newBufferLogger(t, WithNamespace("billing"))
+	log.With("invoice", 7).Infow("paid", "amount", 12.5)
+
+	line := decodeLines(t, buf)[0]
+	nested, ok := line["billing"].(map[string]any)
+	if !ok {
+		t.Fatalf("line = %v, want a billing object", line)
+	}
+	if nested["invoice"] != float64(7) || nested["amount"] != 12.5 {
+		t.Errorf("billing = %v, want the invoice and amount", nested)
+	}
+	if line["service"] != TestService || line["amount"] != nil {
+		t.Errorf("line = %v, want only the service at the top level", line)
+	}
+
+	if _, err := New(TestService, WithNamespace("")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("empty namespace error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestDesugar(t *testing.T) {
+	log, buf := newBufferLogger(t, WithFields(map[string]any{"region": "eu"}), WithLevel("warn"))
+	fast := Desugar(log)
+	fast.Info("dropped", zap.String("path", "/"))
+	fast.Warn("slow request", zap.String("path", "/"), zap.Int("status", 200))
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want only the warning: %q", len(lines), buf.String())
+	}
+	line := lines[0]
+	if line["service"] != TestService || line["region"] != "eu" || line["path"] != "/" || line["status"] != float64(200) {
+		t.Errorf("line = %v, want the sugared logger's fields and its own", line)
+	}
+	if _, ok := line["caller"]; !ok {
+		t.Errorf("line = %v, want a caller", line)
+	}
+}
+
+func BenchmarkDesugar(b *testing.B) {
+	log, err := New(TestService, WithOutputPaths(), WithOutputWriter(io.Discard), WithoutSampling())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer Shutdown(log)
+
+	b.Run("sugared", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.Infow("request", "path", "/", "status", 200)
+		}
+	})
+	b.Run("desugared", func(b *testing.B) {
+		fast := Desugar(log)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fast.Info("request", zap.String("path", "/"), zap.Int("status", 200))
+		}
+	})
+}
+
+func TestNamed(t *testing.T) {
+	log, buf := newBufferLogger(t, WithFields(map[string]any{"region": "eu"}))
+	Named(Named(log, "db"), "pool").Info("opened")
+
+	log, gcp := newBufferLogger(t, WithGCPMapping())
+	Named(log, "cache").Info("hit")
+
+	if line := decodeLines(t, buf)[0]; line["logger"] != "db.pool" || line["region"] != "eu" {
+		t.Errorf("line = %v, want logger db.pool and the parent's fields", line)
+	}
+	if line := decodeLines(t, gcp)[0]; line["logger"] != "cache" {
+		t.Errorf("GCP line = %v, want logger cache", line)
+	}
+}
+
+func TestReplaceGlobals(t *testing.T) {
+	log, buf := newBufferLogger(t, WithFields(map[string]any{"region": "eu"}))
+	restore := ReplaceGlobals(log)
+	zap.S().Infow("from a library", "k", "v")
+	zap.L().Info("typed")
+	restore()
+	zap.S().Info("after restore")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want the 2 logged before restoring", len(lines))
+	}
+	if lines[0]["msg"] != "from a library" || lines[0]["region"] != "eu" || lines[0]["service"] != TestService {
+		t.Errorf("line = %v, want it to go through the configured logger", lines[0])
+	}
+}
+
+func TestNewStdLoggerAt(t *testing.T) {
+	core, logs := observer.New(TraceLevel)
+	log := zap.New(core).Sugar()
+
+	std, err := NewStdLoggerAt(log, "warn")
+	if err != nil {
+		t.Fatalf("NewStdLoggerAt: %v", err)
+	}
+	std.Print("legacy warning")
+	NewStdLogger(log).Print("legacy info")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Level != zap.WarnLevel || entries[0].Message != "legacy warning" {
+		t.Errorf("entry = %v %q, want warn %q", entries[0].Level, entries[0].Message, "legacy warning")
+	}
+	if entries[1].Level != zap.InfoLevel {
+		t.Errorf("NewStdLogger level = %v, want info", entries[1].Level)
+	}
+
+	if _, err := NewStdLoggerAt(log, "loud"); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("unknown level error = %v, want ErrUnknownLevel", err)
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	core, logs := observer.New(TraceLevel)
+	restore := RedirectStdLog(zap.New(core).Sugar())
+	stdlog.Println("from a dependency")
+	restore()
+	stdlog.SetOutput(io.Discard)
+	stdlog.Println("after restore")
+	stdlog.SetOutput(os.Stderr)
+
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].Message != "from a dependency" || entries[0].Level != zap.InfoLevel {
+		t.Errorf("entries = %v, want the line logged before restoring at info", entries)
+	}
+}
+
+func TestWithLevelAliases(t *testing.T) {
+	t.Setenv("LOGGER_TEST_LEVEL", "Notice")
+	aliases := WithLevelAliases(map[string]string{"verbose": "debug", "notice": "info"})
+
+	_, level, err := NewWithLevel(TestService, WithOutputPaths(), aliases, WithLevel("VERBOSE"))
+	if err != nil {
+		t.Fatalf("NewWithLevel: %v", err)
+	}
+	if level.Level() != zap.DebugLevel {
+		t.Errorf("level = %s, want debug", level.Level())
+	}
+	if _, level, err = NewWithLevel(TestService, WithOutputPaths(), aliases, WithLevelFromEnv("LOGGER_TEST_LEVEL")); err != nil || level.Level() != zap.InfoLevel {
+		t.Errorf("level from env = %s, %v; want info", level.Level(), err)
+	}
+
+	// Aliases only apply to the options after them.
+	if _, err := New(TestService, WithLevel("verbose"), aliases); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("alias before WithLevelAliases error = %v, want ErrUnknownLevel", err)
+	}
+	if _, err := New(TestService, WithLevelAliases(map[string]string{"Warn": "error"})); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("built-in collision error = %v, want ErrInvalidOption", err)
+	}
+	if _, err := New(TestService, WithLevelAliases(map[string]string{"chatty": "loud"})); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("alias to an unknown level error = %v, want ErrUnknownLevel", err)
+	}
+}
+
+func TestWithOrderedFields(t *testing.T) {
+	var outputs []string
+	for i := 0; i < 5; i++ {
+		log, buf := newBufferLogger(t,
+			WithEncoding("console"),
+			WithDisableCaller(),
+			WithClock(fixedClock{time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)}),
+			WithFields(map[string]any{"zone": "a", "region": "eu", "build": 7}),
+			WithOrderedFields("z", 1, "a", 2),
+		)
+		log.Infow("hello", "k", "v")
+		outputs = append(outputs, buf.String())
+	}
+
+	want := "2023-12-01T10:00:00.000Z\tINFO\thello\t" +
+		`{"build": 7, "region": "eu", "service": "test", "zone": "a", "z": 1, "a": 2, "k": "v"}` + "\n"
+	for i, out := range outputs {
+		if out != want {
+			t.Errorf("run %d = %q, want %q", i, out, want)
+		}
+	}
+
+	if _, err := New(TestService, WithOrderedFields("odd")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("odd pairs error = %v, want ErrInvalidOption", err)
+	}
+	if _, err := New(TestService, WithOrderedFields(1, "v")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("non-string key error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithHostInfo(t *testing.T) {
+	log, buf := newBufferLogger(t, WithHostInfo())
+	log.Info("hello")
+
+	line := decodeLines(t, buf)[0]
+	want, err := os.Hostname()
+	if err != nil || want == "" {
+		want = "unknown"
+	}
+	if line["host"] != want {
+		t.Errorf("host = %v, want %s", line["host"], want)
+	}
+	if line["pid"] != float64(os.Getpid()) {
+		t.Errorf("pid = %v, want %d", line["pid"], os.Getpid())
+	}
+}
+
+func TestWithBuildInfo(t *testing.T) {
+	log, buf := newBufferLogger(t, WithBuildInfo())
+	log.Info("hello")
+	line := decodeLines(t, buf)[0]
+	if line["go_version"] != runtime.Version() {
+		t.Errorf("go_version = %v, want %s", line["go_version"], runtime.Version())
+	}
+
+	log, buf = newBufferLogger(t, WithBuildInfo(), WithServiceVersion("1.2.3"))
+	log.Info("hello")
+	if line := decodeLines(t, buf)[0]; line["version"] != "1.2.3" || line["go_version"] == nil {
+		t.Errorf("line = %v, want the given version to win over the build info", line)
+	}
+}
+
+func TestWithEncoderConfig(t *testing.T) {
+	log, buf := newBufferLogger(t, WithGCPMapping(), WithEncoderConfig(func(cfg *zapcore.EncoderConfig) {
+		cfg.MessageKey = "text"
+	}))
+	log.Info("hello")
+
+	line := decodeLines(t, buf)[0]
+	if line["text"] != "hello" || line["message"] != nil {
+		t.Errorf("line = %v, want the message under text", line)
+	}
+	if line["severity"] != "INFO" {
+		t.Errorf("severity = %v, want the GCP mapping kept", line["severity"])
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// WithGCPMapping rewrites the zap config to utilize encoding values to conform
+// to the standards used on Google Cloud logging systems. For more information
+// refer to the following Github Issue/Discussion
+// https://github.com/uber-go/zap/discussions/1110#discussioncomment-2955566
+func WithGCPMapping() loggerOpt {
+	return func(b *builder) error {
+		cfg := &b.cfg
+		cfg.EncoderConfig.TimeKey = "time"
+		cfg.EncoderConfig.LevelKey = "severity"
+		cfg.EncoderConfig.NameKey = "logger"
+		cfg.EncoderConfig.CallerKey = "caller"
+		cfg.EncoderConfig.MessageKey = "message"
+		cfg.EncoderConfig.StacktraceKey = "stacktrace"
+		cfg.EncoderConfig.LineEnding = zapcore.DefaultLineEnding
+		cfg.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+		cfg.EncoderConfig.EncodeDuration = zapcore.MillisDurationEncoder
+		cfg.EncoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+		cfg.EncoderConfig.EncodeLevel = func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			switch l {
+			case zapcore.DebugLevel:
+				enc.AppendString("DEBUG")
+			case zapcore.InfoLevel:
+				enc.AppendString("INFO")
+			case zapcore.WarnLevel:
+				enc.AppendString("WARNING")
+			case zapcore.ErrorLevel:
+				enc.AppendString("ERROR")
+			case zapcore.DPanicLevel:
+				enc.AppendString("CRITICAL")
+			case zapcore.PanicLevel:
+				enc.AppendString("ALERT")
+			case zapcore.FatalLevel:
+				enc.AppendString("EMERGENCY")
+			}
+		}
+		return nil
+	}
+}
+
+// WithDatadogMapping rewrites the zap config to use the reserved attributes of
+// Datadog's log pipeline: the level is reported under "status" with Datadog's
+// status names, the message under "message", and the time under "timestamp"
+// in RFC3339.
+func WithDatadogMapping() loggerOpt {
+	return func(b *builder) error {
+		cfg := &b.cfg
+		cfg.EncoderConfig.TimeKey = "timestamp"
+		cfg.EncoderConfig.LevelKey = "status"
+		cfg.EncoderConfig.MessageKey = "message"
+		cfg.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+		cfg.EncoderConfig.EncodeLevel = func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			switch l {
+			case zapcore.DebugLevel:
+				enc.AppendString("debug")
+			case zapcore.InfoLevel:
+				enc.AppendString("info")
+			case zapcore.WarnLevel:
+				enc.AppendString("warning")
+			case zapcore.ErrorLevel:
+				enc.AppendString("error")
+			case zapcore.DPanicLevel:
+				enc.AppendString("critical")
+			case zapcore.PanicLevel:
+				enc.AppendString("alert")
+			case zapcore.FatalLevel:
+				enc.AppendString("emergency")
+			}
+		}
+		return nil
+	}
+}
+
+// WithCloudWatchMapping rewrites the zap config for AWS CloudWatch Logs, as
+// used by Lambda: JSON entries with an upper-case "level", a "message", and
+// the time as epoch milliseconds under "timestamp", which Logs Insights can
+// filter and sort on directly.
+//
+// Lambda may freeze the process as soon as a handler returns, losing anything
+// still buffered, so flush at the end of every invocation:
+//
+//	func handler(ctx context.Context, ev Event) error {
+//		defer log.Sync()
+//		...
+//	}
+func WithCloudWatchMapping() loggerOpt {
+	return func(b *builder) error {
+		cfg := &b.cfg
+		cfg.Encoding = "json"
+		cfg.EncoderConfig.TimeKey = "timestamp"
+		cfg.EncoderConfig.LevelKey = "level"
+		cfg.EncoderConfig.MessageKey = "message"
+		cfg.EncoderConfig.EncodeTime = zapcore.EpochMillisTimeEncoder
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		return nil
+	}
+}
+
+// WithLogstashMapping rewrites the zap config for Logstash and Filebeat
+// shipping to Elasticsearch: the time is reported under "@timestamp" in
+// RFC3339 with nanoseconds, the message under "message", the lower-case level
+// under "level", and every entry carries "@version":"1".
+func WithLogstashMapping() loggerOpt {
+	return func(b *builder) error {
+		cfg := &b.cfg
+		cfg.Encoding = "json"
+		cfg.EncoderConfig.TimeKey = "@timestamp"
+		cfg.EncoderConfig.LevelKey = "level"
+		cfg.EncoderConfig.MessageKey = "message"
+		cfg.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		cfg.EncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		return WithFields(map[string]any{"@version": "1"})(b)
+	}
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithGCPMapping(t *testing.T) {
+	log, buf := newBufferLogger(t, WithGCPMapping())
+	log.Warn("careful")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	line := lines[0]
+	if line["severity"] != "WARNING" || line["message"] != "careful" {
+		t.Errorf("line = %v, want severity WARNING and message careful", line)
+	}
+	if _, ok := line["time"].(string); !ok {
+		t.Errorf("time = %v, want an RFC3339 string", line["time"])
+	}
+	for _, key := range []string{"level", "msg", "ts"} {
+		if _, ok := line[key]; ok {
+			t.Errorf("line still has zap's %q key", key)
+		}
+	}
+}
+
+func TestWithDatadogMapping(t *testing.T) {
+	log, buf := newBufferLogger(t, WithDatadogMapping())
+	log.Warn("careful")
+	log.Error("failed")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, want := range []struct{ status, message string }{{"warning", "careful"}, {"error", "failed"}} {
+		if lines[i]["status"] != want.status || lines[i]["message"] != want.message {
+			t.Errorf("line %d = %v, want status %s and message %s", i, lines[i], want.status, want.message)
+		}
+	}
+	if ts, _ := lines[0]["timestamp"].(string); ts == "" {
+		t.Errorf("timestamp = %v, want an RFC3339 string", lines[0]["timestamp"])
+	}
+}
+
+func TestWithCloudWatchMapping(t *testing.T) {
+	log, buf := newBufferLogger(t, WithCloudWatchMapping())
+	before := time.Now().UnixMilli()
+	log.Info("invoked")
+	after := time.Now().UnixMilli()
+
+	line := decodeLines(t, buf)[0]
+	ts, ok := line["timestamp"].(float64)
+	if !ok {
+		t.Fatalf("timestamp = %v, want a number", line["timestamp"])
+	}
+	if ms := int64(ts); ms < before || ms > after {
+		t.Errorf("timestamp = %d, want epoch millis between %d and %d", ms, before, after)
+	}
+	if line["level"] != "INFO" || line["message"] != "invoked" {
+		t.Errorf("line = %v, want level INFO and message invoked", line)
+	}
+}
+
+func TestWithLogstashMapping(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLogstashMapping())
+	log.Error("failed")
+
+	line := decodeLines(t, buf)[0]
+	if line["@version"] != "1" || line["level"] != "error" || line["message"] != "failed" {
+		t.Errorf("line = %v, want @version 1, level error and the message", line)
+	}
+	ts, _ := line["@timestamp"].(string)
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		t.Errorf("@timestamp = %q: %v", ts, err)
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithValueMasking replaces every match of patterns in the message and in
+// string fields, whatever their key, with one asterisk per character matched,
+// leaving the rest of the text alone. For example, to hide card numbers:
+//
+//	WithValueMasking(regexp.MustCompile(`\b\d(?:[ -]?\d){12,15}\b`))
+func WithValueMasking(patterns ...*regexp.Regexp) loggerOpt {
+	return func(b *builder) error {
+		if len(patterns) == 0 {
+			return nil
+		}
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			m := masker(patterns)
+			return &rewriteCore{Core: core, field: m.field, entry: m.entry}
+		})
+		return nil
+	}
+}
+
+// masker masks the matches of its patterns.
+type masker []*regexp.Regexp
+
+// field masks string fields.
+func (m masker) field(f zapcore.Field) (zapcore.Field, fieldEdit) {
+	if f.Type != zapcore.StringType {
+		return f, fieldKept
+	}
+	s := m.mask(f.String)
+	if s == f.String {
+		return f, fieldKept
+	}
+	f.String = s
+	return f, fieldReplaced
+}
+
+// entry masks the message.
+func (m masker) entry(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+	ent.Message = m.mask(ent.Message)
+	return ent, fields
+}
+
+// mask returns s with every match replaced. s is returned as is, without
+// allocating, when nothing matches.
+func (m masker) mask(s string) string {
+	for _, re := range m {
+		if !re.MatchString(s) {
+			continue
+		}
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(match))
+		})
+	}
+	return s
+}
@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestWithValueMasking(t *testing.T) {
+	card := regexp.MustCompile(`\b\d(?:[ -]?\d){12,15}\b`)
+	log, buf := newBufferLogger(t, WithValueMasking(card))
+	log.With("note", "card 4111-1111-1111-1111 on file").
+		Infow("charged 4111 1111 1111 1111 for order 42", "last", "4111111111111111", "amount", 4111111111111111)
+
+	line := decodeLines(t, buf)[0]
+	want := map[string]any{
+		"msg":    "charged ******************* for order 42",
+		"note":   "card ******************* on file",
+		"last":   "****************",
+		"amount": float64(4111111111111111),
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithMetrics registers a `log_entries_total` counter, labeled by level, with
+// registerer and increments it for every entry written. Entries dropped by
+// sampling or filtered by level aren't counted. Loggers sharing a registerer
+// share the counter.
+func WithMetrics(registerer prometheus.Registerer) loggerOpt {
+	return func(b *builder) error {
+		counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "log_entries_total",
+			Help: "Number of log entries written, by level.",
+		}, []string{"level"})
+
+		if err := registerer.Register(counter); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				return err
+			}
+			existing, ok := are.ExistingCollector.(*prometheus.CounterVec)
+			if !ok {
+				return err
+			}
+			counter = existing
+		}
+
+		return WithZapOptions(zap.Hooks(func(ent zapcore.Entry) error {
+			counter.WithLabelValues(levelName(ent.Level)).Inc()
+			return nil
+		}))(b)
+	}
+}
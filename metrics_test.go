@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	log, _ := newBufferLogger(t, WithSampling(1, 0), WithMetrics(reg))
+	log.Debug("filtered")
+	log.Info("one")
+	log.Warn("two")
+	log.Error("three")
+	log.Error("four")
+	log.Error("four") // dropped by sampling
+
+	// A second logger on the same registry shares the counter.
+	log2, _ := newBufferLogger(t, WithMetrics(reg))
+	log2.Error("five")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, mf := range families {
+		if mf.GetName() != "log_entries_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			got[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	want := map[string]float64{"info": 1, "warn": 1, "error": 3}
+	if len(got) != len(want) {
+		t.Errorf("counts = %v, want %v", got, want)
+	}
+	for level, n := range want {
+		if got[level] != n {
+			t.Errorf("log_entries_total{level=%q} = %v, want %v", level, got[level], n)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithNamedLevels sets the minimum level of loggers by name, as given to
+// Named, overriding the logger's level in either direction. A name ending in
+// ".*" covers the logger with the name before it and every logger named below
+// that, so {"db": "warn", "http.*": "debug"} quiets the "db" logger while
+// making "http.client" and "http.server" verbose. The most specific match
+// wins, and loggers with no match use the logger's level.
+func WithNamedLevels(levels map[string]string) loggerOpt {
+	return func(b *builder) error {
+		parsed := make(map[string]zapcore.Level, len(levels))
+		minLevel := zapcore.InvalidLevel
+		for name, level := range levels {
+			lvl, err := b.parseLevel(level)
+			if err != nil {
+				return err
+			}
+			parsed[name] = lvl
+			if minLevel == zapcore.InvalidLevel || lvl < minLevel {
+				minLevel = lvl
+			}
+		}
+		if len(parsed) == 0 {
+			return nil
+		}
+
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &namedLevelCore{Core: core, levels: parsed, min: minLevel}
+		})
+		return nil
+	}
+}
+
+// namedLevelCore filters entries by the level set for their logger's name.
+type namedLevelCore struct {
+	zapcore.Core
+	levels map[string]zapcore.Level
+	// min is the lowest level of any name, which must pass Enabled for zap
+	// to check the entry at all.
+	min zapcore.Level
+}
+
+func (c *namedLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedLevelCore{Core: c.Core.With(fields), levels: c.levels, min: c.min}
+}
+
+func (c *namedLevelCore) Enabled(l zapcore.Level) bool {
+	return l >= c.min || c.Core.Enabled(l)
+}
+
+func (c *namedLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.enabled(ent) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *namedLevelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// Wrapping cores may write without checking the entry's name.
+	if !c.enabled(ent) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// enabled reports whether ent passes the level for its logger's name, or the
+// wrapped core's level if no name matches.
+func (c *namedLevelCore) enabled(ent zapcore.Entry) bool {
+	if lvl, ok := c.levelFor(ent.LoggerName); ok {
+		return ent.Level >= lvl
+	}
+	return c.Core.Enabled(ent.Level)
+}
+
+// levelFor finds the level for name: an exact match, or else the wildcard
+// for its longest dotted prefix.
+func (c *namedLevelCore) levelFor(name string) (zapcore.Level, bool) {
+	if name == "" {
+		return 0, false
+	}
+	if lvl, ok := c.levels[name]; ok {
+		return lvl, true
+	}
+	for prefix := name; ; {
+		if lvl, ok := c.levels[prefix+".*"]; ok {
+			return lvl, true
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			return 0, false
+		}
+		prefix = prefix[:i]
+	}
+}
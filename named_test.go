@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithNamedLevels(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLevel("info"), WithoutSampling(), WithNamedLevels(map[string]string{
+		"db":     "warn",
+		"http.*": "debug",
+	}))
+	for _, name := range []string{"db", "db.pool", "http", "http.client", "cache"} {
+		named := Named(log, name)
+		named.Debug("debug")
+		named.Info("info")
+		named.Warn("warn")
+	}
+	log.Debug("debug")
+	log.Info("info")
+
+	got := map[string][]string{}
+	for _, line := range decodeLines(t, buf) {
+		name, _ := line["logger"].(string)
+		got[name] = append(got[name], line["msg"].(string))
+	}
+	want := map[string][]string{
+		// db.pool has no wildcard to match, so it uses the logger's level.
+		"db":          {"warn"},
+		"db.pool":     {"info", "warn"},
+		"http":        {"debug", "info", "warn"},
+		"http.client": {"debug", "info", "warn"},
+		"cache":       {"info", "warn"},
+		"":            {"info"},
+	}
+	for name, msgs := range want {
+		if len(got[name]) != len(msgs) {
+			t.Errorf("logger %q wrote %v, want %v", name, got[name], msgs)
+			continue
+		}
+		for i := range msgs {
+			if got[name][i] != msgs[i] {
+				t.Errorf("logger %q wrote %v, want %v", name, got[name], msgs)
+				break
+			}
+		}
+	}
+
+	if _, err := New(TestService, WithNamedLevels(map[string]string{"db": "loud"})); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("unknown level error = %v, want ErrUnknownLevel", err)
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// TraceFields returns "trace_id" and "span_id" fields for the OpenTelemetry
+// span active in ctx, or no fields when there is no valid span.
+func TraceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
+
+// TraceLogger returns log annotated with the trace and span IDs of the span
+// active in ctx, so entries can be correlated with the trace. log is returned
+// unchanged when there is no valid span.
+func TraceLogger(ctx context.Context, log *zap.SugaredLogger) *zap.SugaredLogger {
+	fields := TraceFields(ctx)
+	if len(fields) == 0 {
+		return log
+	}
+	return log.Desugar().With(fields...).Sugar()
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceLogger(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	log, buf := newBufferLogger(t)
+	TraceLogger(ctx, log).Info("in span")
+	TraceLogger(context.Background(), log).Info("no span")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if lines[0]["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || lines[0]["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("span line = %v, want its trace and span IDs", lines[0])
+	}
+	if _, ok := lines[1]["trace_id"]; ok {
+		t.Errorf("line without a span = %v, want no IDs", lines[1])
+	}
+	if fields := TraceFields(context.Background()); fields != nil {
+		t.Errorf("TraceFields without a span = %v, want none", fields)
+	}
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// writerScheme is the sink scheme used to hand WriteSyncers built by options
+// to zap.Config, which only knows about output paths.
+const writerScheme = "logger-writer"
+
+var (
+	writerOnce sync.Once
+	writerErr  error
+
+	writers  sync.Map // id -> func() (zapcore.WriteSyncer, error)
+	writerID atomic.Uint64
+
+	sinksMu sync.Mutex
+	sinks   = map[string]struct{}{} // schemes registered by WithSink
+)
+
+// WithSink registers factory with zap for output paths using scheme, such as
+// "mysink://host/path", so the scheme can be passed to WithOutputPaths. zap
+// keeps sink registrations for the life of the program: the first factory
+// given for a scheme is used by every logger, and later calls for the same
+// scheme are no-ops.
+func WithSink(scheme string, factory func(*url.URL) (zap.Sink, error)) loggerOpt {
+	return func(b *builder) error {
+		sinksMu.Lock()
+		defer sinksMu.Unlock()
+
+		key := strings.ToLower(scheme)
+		if _, ok := sinks[key]; ok {
+			return nil
+		}
+		if err := zap.RegisterSink(scheme, factory); err != nil {
+			return err
+		}
+		sinks[key] = struct{}{}
+		return nil
+	}
+}
+
+// WithOutputWriter adds w to the outputs, such as a bytes.Buffer in tests. To
+// write only to w, clear the default stdout output with `WithOutputPaths()`.
+// With WithLevelRouting, w gets every entry. w is synced by Sync if it
+// implements zapcore.WriteSyncer.
+func WithOutputWriter(w io.Writer) loggerOpt {
+	return func(b *builder) error {
+		ws := zapcore.AddSync(w)
+		return b.addWriter(func() (zapcore.WriteSyncer, error) { return ws, nil })
+	}
+}
+
+// addWriter adds the writer returned by open to the outputs. open is
+// called when the logger is built, so outputs that start goroutines or
+// connections only do so once every option has been applied. If the logger
+// never opens the path, it's unregistered by the closers run on failure.
+func (b *builder) addWriter(open func() (zapcore.WriteSyncer, error)) error {
+	path, err := registerOpener(open)
+	if err != nil {
+		return err
+	}
+	b.onShutdown(func() error {
+		unregisterWriter(path)
+		return nil
+	})
+	b.extraPaths = append(b.extraPaths, path)
+	return nil
+}
+
+// registerWriter returns the output path that opens ws. Each writer is
+// registered under a unique path that is removed from the registry once zap
+// opens it.
+func registerWriter(ws zapcore.WriteSyncer) (string, error) {
+	return registerOpener(func() (zapcore.WriteSyncer, error) { return ws, nil })
+}
+
+// registerOpener returns the output path that opens the writer returned by
+// open, which is called when zap opens the path.
+func registerOpener(open func() (zapcore.WriteSyncer, error)) (string, error) {
+	writerOnce.Do(func() {
+		writerErr = zap.RegisterSink(writerScheme, openWriter)
+	})
+	if writerErr != nil {
+		return "", writerErr
+	}
+
+	id := strconv.FormatUint(writerID.Add(1), 10)
+	writers.Store(id, open)
+	return writerScheme + ":" + id, nil
+}
+
+// unregisterWriter removes the writer registered under path if zap hasn't
+// opened it, for a logger that failed to build.
+func unregisterWriter(path string) {
+	writers.Delete(strings.TrimPrefix(path, writerScheme+":"))
+}
+
+func openWriter(u *url.URL) (zap.Sink, error) {
+	open, ok := writers.LoadAndDelete(u.Opaque)
+	if !ok {
+		return nil, fmt.Errorf("no writer registered for %q", u)
+	}
+	ws, err := open.(func() (zapcore.WriteSyncer, error))()
+	if err != nil {
+		return nil, err
+	}
+	return writerSink{ws}, nil
+}
+
+// writerSink adapts a zapcore.WriteSyncer to zap.Sink.
+type writerSink struct {
+	zapcore.WriteSyncer
+}
+
+func (s writerSink) Close() error {
+	if c, ok := s.WriteSyncer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
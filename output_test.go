@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWithOutputWriter(t *testing.T) {
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "app.log")
+	// The writer is kept whichever order the options come in.
+	log, err := New(TestService, WithOutputWriter(&buf), WithOutputPaths(path))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	log.Infow("hello", "k", "v")
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("buffer %q isn't JSON: %v", buf.String(), err)
+	}
+	if line["msg"] != "hello" || line["k"] != "v" || line["service"] != TestService {
+		t.Errorf("line = %v, want the entry", line)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "hello") {
+		t.Errorf("file = %q, want the entry there too", data)
+	}
+}
+
+// memorySink is a zap.Sink writing to a syncBuffer.
+type memorySink struct{ *syncBuffer }
+
+func (memorySink) Sync() error  { return nil }
+func (memorySink) Close() error { return nil }
+
+func TestWithSink(t *testing.T) {
+	buf := &syncBuffer{}
+	factory := func(u *url.URL) (zap.Sink, error) {
+		if u.Host != "audit" {
+			return nil, fmt.Errorf("unknown host %q", u.Host)
+		}
+		return memorySink{buf}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		// Registering the scheme again is a no-op rather than an error.
+		log, err := New(TestService, WithSink("memtest", factory), WithOutputPaths("memtest://audit"))
+		if err != nil {
+			t.Fatalf("New %d: %v", i, err)
+		}
+		log.Infow("arrived", "run", i)
+		_ = Shutdown(log)
+	}
+
+	if got := len(buf.lines()); got != 2 {
+		t.Errorf("got %d lines in the sink, want 2", got)
+	}
+	if _, err := New(TestService, WithSink("memtest", factory), WithOutputPaths("memtest://other")); err == nil {
+		t.Error("New succeeded though the sink factory failed")
+	}
+}
+
+// redirect points *f, such as os.Stdout, at a temporary file for the rest of
+// the test and returns a function reading what was written to it.
+func redirect(t *testing.T, f **os.File) func() string {
+	t.Helper()
+	tmp, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *f
+	*f = tmp
+	t.Cleanup(func() {
+		*f = orig
+		tmp.Close()
+	})
+	return func() string {
+		data, _ := os.ReadFile(tmp.Name())
+		return string(data)
+	}
+}
+
+func TestWithOutputPathsFallback(t *testing.T) {
+	stdout := redirect(t, &os.Stdout)
+	stderr := redirect(t, &os.Stderr)
+	missing := filepath.Join(t.TempDir(), "missing", "app.log")
+
+	var extra syncBuffer
+	log, err := New(TestService, WithOutputWriter(&extra), WithOutputPathsFallback([]string{missing}, []string{"stdout"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	log.Info("still logging")
+	_ = Shutdown(log)
+
+	if !strings.Contains(stdout(), "still logging") {
+		t.Errorf("stdout = %q, want the entry", stdout())
+	}
+	if !strings.Contains(extra.String(), "still logging") {
+		t.Errorf("extra output = %q, want the entry", extra.String())
+	}
+	if msg := stderr(); !strings.Contains(msg, missing) || !strings.Contains(msg, "writing to stdout instead") {
+		t.Errorf("stderr = %q, want the reason for falling back", msg)
+	}
+
+	if _, err := New(TestService, WithOutputPathsFallback([]string{missing}, nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("empty fallback error = %v, want ErrInvalidOption", err)
+	}
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const prettyJSONEncoding = "pretty-json"
+
+var (
+	prettyOnce sync.Once
+	prettyErr  error
+
+	prettyPool = buffer.NewPool()
+)
+
+// WithPrettyJSON switches to JSON output indented over several lines, for
+// reading logs by eye while developing. Each entry is encoded as usual and
+// then re-indented, roughly doubling the cost of encoding, and most log
+// collectors expect one entry per line, so don't use it in production.
+func WithPrettyJSON() loggerOpt {
+	return func(b *builder) error {
+		prettyOnce.Do(func() {
+			prettyErr = zap.RegisterEncoder(prettyJSONEncoding, newPrettyJSONEncoder)
+		})
+		if prettyErr != nil {
+			return prettyErr
+		}
+
+		b.cfg.Encoding = prettyJSONEncoding
+		return nil
+	}
+}
+
+// prettyJSONEncoder indents the output of the JSON encoder.
+type prettyJSONEncoder struct {
+	zapcore.Encoder
+	lineEnding string
+}
+
+func newPrettyJSONEncoder(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	lineEnding := cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	return &prettyJSONEncoder{Encoder: zapcore.NewJSONEncoder(cfg), lineEnding: lineEnding}, nil
+}
+
+func (e *prettyJSONEncoder) Clone() zapcore.Encoder {
+	return &prettyJSONEncoder{Encoder: e.Encoder.Clone(), lineEnding: e.lineEnding}
+}
+
+func (e *prettyJSONEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer line.Free()
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSuffix(line.Bytes(), []byte(e.lineEnding)), "", "  "); err != nil {
+		return nil, err
+	}
+
+	buf := prettyPool.Get()
+	buf.Write(indented.Bytes())
+	buf.AppendString(e.lineEnding)
+	return buf, nil
+}
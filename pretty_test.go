@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWithPrettyJSON(t *testing.T) {
+	log, buf := newBufferLogger(t, WithPrettyJSON())
+	log.Infow("first", "user", map[string]any{"name": "bob"})
+	log.Info("second")
+
+	out := buf.String()
+	if !strings.Contains(out, "\n  \"msg\": \"first\"") || !strings.Contains(out, "\n    \"name\": \"bob\"") {
+		t.Errorf("output isn't indented: %q", out)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(out))
+	for _, want := range []string{"first", "second"} {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("output %q isn't a stream of JSON objects: %v", out, err)
+		}
+		if entry["msg"] != want || entry["service"] != TestService {
+			t.Errorf("entry = %v, want %s", entry, want)
+		}
+	}
+}
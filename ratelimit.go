@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit caps the logger at perSecond entries per second, allowing
+// bursts of up to burst entries. Unlike sampling, the limit applies across all
+// messages. Entries over the limit are dropped and counted; the count is
+// reported in a Warn entry with a "dropped" field ahead of the next entry that
+// gets through, on Sync, or a second after the first drop, whichever is first.
+// The summary is skipped if the logger's level is above Warn.
+func WithRateLimit(perSecond int, burst int) loggerOpt {
+	return func(b *builder) error {
+		if perSecond <= 0 || burst <= 0 {
+			return fmt.Errorf("%w: rate limit perSecond (%d) and burst (%d) must be positive", ErrInvalidOption, perSecond, burst)
+		}
+		limiter := &rateLimiter{limiter: rate.NewLimiter(rate.Limit(perSecond), burst)}
+		b.onShutdown(limiter.stop)
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rateLimitCore{Core: core, rateLimiter: limiter}
+		})
+		return nil
+	}
+}
+
+// rateLimitReport is how long after the first dropped entry the count is
+// reported if no entry gets through and the logger isn't synced first.
+const rateLimitReport = time.Second
+
+// rateLimiter is shared by a rateLimitCore and every core derived from it.
+type rateLimiter struct {
+	limiter *rate.Limiter
+	dropped atomic.Int64
+
+	// timer reports the dropped count through core once rateLimitReport has
+	// passed since the first drop.
+	mu      sync.Mutex
+	timer   *time.Timer
+	core    zapcore.Core
+	stopped bool
+}
+
+// schedule arranges for the dropped count to be reported through core, unless
+// a report is already due.
+func (r *rateLimiter) schedule(core zapcore.Core) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil || r.stopped {
+		return
+	}
+	r.core = core
+	r.timer = time.AfterFunc(rateLimitReport, func() {
+		r.mu.Lock()
+		core := r.core
+		r.timer, r.core = nil, nil
+		r.mu.Unlock()
+		_ = r.report(core, time.Now())
+	})
+}
+
+// stop cancels any pending report, for Shutdown.
+func (r *rateLimiter) stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer, r.core = nil, nil
+	}
+	return nil
+}
+
+// report writes a summary of the entries dropped since the last one to core,
+// unless core has Warn disabled.
+func (r *rateLimiter) report(core zapcore.Core, t time.Time) error {
+	n := r.dropped.Swap(0)
+	if n == 0 || !core.Enabled(zapcore.WarnLevel) {
+		return nil
+	}
+	ent := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    t,
+		Message: "log entries dropped by rate limit",
+	}
+	return core.Write(ent, []zapcore.Field{zap.Int64("dropped", n)})
+}
+
+type rateLimitCore struct {
+	zapcore.Core
+	*rateLimiter
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), rateLimiter: c.rateLimiter}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if !c.limiter.Allow() {
+		if c.dropped.Add(1) == 1 {
+			c.schedule(c.Core)
+		}
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *rateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.reportDropped(ent.Time); err != nil {
+		return err
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func (c *rateLimitCore) Sync() error {
+	if err := c.reportDropped(time.Now()); err != nil {
+		return err
+	}
+	return c.Core.Sync()
+}
+
+// reportDropped writes a summary of the entries dropped since the last one.
+func (c *rateLimitCore) reportDropped(t time.Time) error {
+	return c.report(c.Core, t)
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	log, buf := newBufferLogger(t, WithoutSampling(), WithRateLimit(1, 5))
+	for i := 0; i < 1000; i++ {
+		log.Errorw("storm", "i", i)
+	}
+	if got := len(buf.lines()); got != 5 {
+		t.Errorf("got %d lines, want the burst of 5", got)
+	}
+
+	_ = log.Sync()
+	lines := decodeLines(t, buf)
+	if len(lines) != 6 {
+		t.Fatalf("got %d lines after Sync, want 6", len(lines))
+	}
+	if summary := lines[5]; summary["level"] != "warn" || summary["dropped"] != float64(995) {
+		t.Errorf("summary = %v, want a warning with 995 dropped", summary)
+	}
+
+	// Above Warn, the summary is left out like any other warning.
+	log, buf = newBufferLogger(t, WithoutSampling(), WithLevel("error"), WithRateLimit(1, 1))
+	log.Error("storm")
+	log.Error("storm")
+	_ = log.Sync()
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["level"] != "error" {
+		t.Errorf("got %q, want only the first error", buf.String())
+	}
+
+	if _, err := New(TestService, WithRateLimit(0, 1)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("zero rate error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithRateLimitReportsWithoutSync(t *testing.T) {
+	log, buf := newBufferLogger(t, WithoutSampling(), WithRateLimit(1, 2))
+	for i := 0; i < 10; i++ {
+		log.Info("storm")
+	}
+
+	deadline := time.Now().Add(rateLimitReport + time.Second)
+	for len(buf.lines()) < 3 && time.Now().Before(deadline) {
+		waitABit()
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want the burst of 2 and a summary", len(lines))
+	}
+	if lines[2]["dropped"] != float64(8) {
+		t.Errorf("summary = %v, want 8 dropped", lines[2])
+	}
+}
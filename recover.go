@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"os"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// Recover logs a panic at Error, with the panic value under "panic" and the
+// stack of the panicking goroutine under "stacktrace", and stops it from
+// crashing the program. It must be deferred directly, typically at the top of
+// a goroutine: `defer logger.Recover(log)`.
+func Recover(log *zap.SugaredLogger) {
+	if rec := recover(); rec != nil {
+		logPanic(log, rec)
+	}
+}
+
+// RecoverAndExit behaves like Recover but, once the panic is logged, flushes
+// log and exits with status 1, for goroutines the program can't continue
+// without. Like Recover, it must be deferred directly.
+func RecoverAndExit(log *zap.SugaredLogger) {
+	if rec := recover(); rec != nil {
+		logPanic(log, rec)
+		_ = Sync(log)
+		os.Exit(1)
+	}
+}
+
+// logPanic logs rec. It's called from the deferred function while the
+// panicking goroutine's stack is still intact, so the stack shows where the
+// panic happened. The caller is left out since it would only point here.
+func logPanic(log *zap.SugaredLogger, rec any) {
+	log.Desugar().WithOptions(zap.WithCaller(false)).Error("recovered from panic",
+		zap.Any("panic", rec),
+		zap.ByteString("stacktrace", debug.Stack()),
+	)
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer Recover(log)
+		panicky()
+	}()
+	<-done
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	line := lines[0]
+	if line["level"] != "error" || line["panic"] != "boom" {
+		t.Errorf("line = %v, want an error with the panic value", line)
+	}
+	if st, _ := line["stacktrace"].(string); !strings.Contains(st, "panicky") {
+		t.Errorf("stacktrace = %q, want the panicking function in it", st)
+	}
+	if _, ok := line["caller"]; ok {
+		t.Errorf("line has a caller: %v", line["caller"])
+	}
+}
+
+func panicky() {
+	panic("boom")
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	func() {
+		defer Recover(log)
+	}()
+	if out := buf.String(); out != "" {
+		t.Errorf("got %q, want nothing logged", out)
+	}
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// WithRedaction replaces the value of any field whose key matches one of keys
+// with "[REDACTED]". Keys are matched case-insensitively against top-level
+// fields, whether they are passed at the call site or attached with With().
+func WithRedaction(keys ...string) loggerOpt {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+
+	return func(b *builder) error {
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, field: redactField(set)}
+		})
+		return nil
+	}
+}
+
+// redactField replaces the value of fields whose key is in keys.
+func redactField(keys map[string]struct{}) func(zapcore.Field) (zapcore.Field, fieldEdit) {
+	return func(f zapcore.Field) (zapcore.Field, fieldEdit) {
+		if f.Type == zapcore.NamespaceType {
+			return f, fieldKept
+		}
+		if _, ok := keys[strings.ToLower(f.Key)]; !ok {
+			return f, fieldKept
+		}
+		return zap.String(f.Key, redacted), fieldReplaced
+	}
+}
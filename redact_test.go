@@ -0,0 +1,40 @@
+package logger
+
+import "testing"
+
+func TestWithRedaction(t *testing.T) {
+	log, buf := newBufferLogger(t, WithRedaction("password", "Token"))
+
+	log.With("token", "abc").Infow("login", "Password", "hunter2", "user", "bob")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	line := lines[0]
+	if line["Password"] != redacted || line["token"] != redacted {
+		t.Errorf("sensitive fields weren't redacted: %v", line)
+	}
+	if line["user"] != "bob" || line["service"] != TestService {
+		t.Errorf("other fields didn't survive: %v", line)
+	}
+}
+
+func TestWithRedactionCoversTees(t *testing.T) {
+	hub, transport := newFakeHub(t)
+	ringOpt, ring := WithMemoryBuffer(4)
+	// The outputs are given ahead of the redaction but must still only see
+	// redacted values.
+	log, _ := newBufferLogger(t, WithSentry(hub, "error"), ringOpt, WithRedaction("password"))
+	log.Errorw("login failed", "password", "hunter2")
+	_ = log.Sync()
+
+	entries := ring.Entries()
+	if len(entries) != 1 || entries[0].ContextMap()["password"] != redacted {
+		t.Errorf("ring entries = %v, want the password redacted", entries)
+	}
+	events := transport.sent()
+	if len(events) != 1 || events[0].Extra["password"] != redacted {
+		t.Errorf("sentry events = %v, want the password redacted", events)
+	}
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// WithRotateOnSignal reopens file outputs when the process receives sig,
+// typically syscall.SIGHUP, so tools like logrotate can move a log file aside
+// and have the logger continue in a new file at the original path. Outputs
+// other than files are unaffected. Shutdown stops listening for sig.
+func WithRotateOnSignal(sig os.Signal) loggerOpt {
+	return func(b *builder) error {
+		b.reopenSignal = sig
+		return nil
+	}
+}
+
+// WithFileMode creates file outputs with permissions mode, such as 0600 for
+// logs that may hold sensitive data, rather than zap's 0666 less the umask.
+// The permissions of existing files are changed to mode when they're opened.
+func WithFileMode(mode os.FileMode) loggerOpt {
+	return func(b *builder) error {
+		if mode == 0 || mode&^os.ModePerm != 0 {
+			return fmt.Errorf("%w: file mode %s must only have permission bits", ErrInvalidOption, mode)
+		}
+		b.fileMode = mode
+		return nil
+	}
+}
+
+// openFiles replaces the file paths among the outputs with files this package
+// opens itself, so they're created with b.fileMode and reopen on
+// b.reopenSignal. Paths that can't be opened are left for zap to report, or
+// for the fallback paths to replace.
+func (b *builder) openFiles(outputs ...*[]string) error {
+	var files []*reopenFile
+	var registered []string
+	closeFiles := func() error {
+		var errs []error
+		for _, f := range files {
+			errs = append(errs, f.Close())
+		}
+		for _, path := range registered {
+			unregisterWriter(path)
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, paths := range outputs {
+		replaced := make([]string, len(*paths))
+		for i, p := range *paths {
+			replaced[i] = p
+			name, ok := filePath(p)
+			if !ok {
+				continue
+			}
+			f := &reopenFile{name: name, mode: b.fileMode}
+			if err := f.reopen(); err != nil {
+				continue
+			}
+			files = append(files, f)
+			path, err := registerWriter(f)
+			if err != nil {
+				_ = closeFiles()
+				return err
+			}
+			replaced[i] = path
+			registered = append(registered, path)
+		}
+		*paths = replaced
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	if b.reopenSignal == nil {
+		b.onShutdown(closeFiles)
+		return nil
+	}
+
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	signal.Notify(sigs, b.reopenSignal)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-sigs:
+				for _, f := range files {
+					_ = f.reopen()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	b.onShutdown(func() error {
+		signal.Stop(sigs)
+		close(done)
+		<-stopped
+		return closeFiles()
+	})
+	return nil
+}
+
+// reopenFile is an append-only file that can be closed and opened again at
+// the same path. A zero mode leaves the permissions to os.OpenFile.
+type reopenFile struct {
+	name string
+	mode os.FileMode
+
+	mu sync.RWMutex
+	f  *os.File
+}
+
+// reopen opens the file at f.name, creating it if it was moved, and closes the
+// previous one. The previous file is kept if the new one can't be opened.
+func (f *reopenFile) reopen() error {
+	mode := f.mode
+	if mode == 0 {
+		mode = 0o666
+	}
+	nf, err := os.OpenFile(f.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode)
+	if err != nil {
+		return err
+	}
+	if f.mode != 0 {
+		if err := nf.Chmod(f.mode); err != nil {
+			nf.Close()
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	old := f.f
+	f.f = nf
+	f.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (f *reopenFile) Write(p []byte) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.f.Write(p)
+}
+
+func (f *reopenFile) Sync() error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.f.Sync()
+}
+
+func (f *reopenFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Close()
+}
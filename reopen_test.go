@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithRotateOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	moved := filepath.Join(dir, "app.log.1")
+
+	log, err := New(TestService, WithOutputPaths(path), WithRotateOnSignal(syscall.SIGUSR1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer Shutdown(log)
+
+	log.Info("before")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("still the old file")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		waitABit()
+	}
+	log.Info("after")
+	_ = log.Sync()
+
+	old, _ := os.ReadFile(moved)
+	fresh, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("no file recreated at %s: %v", path, err)
+	}
+	if !strings.Contains(string(old), "before") || !strings.Contains(string(old), "still the old file") {
+		t.Errorf("moved file = %q, want the entries before the signal", old)
+	}
+	if !strings.Contains(string(fresh), "after") || strings.Contains(string(fresh), "before") {
+		t.Errorf("new file = %q, want only the entry after the signal", fresh)
+	}
+}
+
+func TestWithFileMode(t *testing.T) {
+	dir := t.TempDir()
+	created := filepath.Join(dir, "new.log")
+	existing := filepath.Join(dir, "old.log")
+	if err := os.WriteFile(existing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := New(TestService, WithOutputPaths(created, existing), WithFileMode(0o600))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	log.Info("secret")
+	_ = Shutdown(log)
+
+	for _, path := range []string{created, existing} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("%s mode = %o, want 600", filepath.Base(path), mode)
+		}
+		if data, _ := os.ReadFile(path); !strings.Contains(string(data), "secret") {
+			t.Errorf("%s = %q, want the entry", filepath.Base(path), data)
+		}
+	}
+
+	if _, err := New(TestService, WithFileMode(os.ModeDir|0o600)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("non-permission bits error = %v, want ErrInvalidOption", err)
+	}
+}
@@ -0,0 +1,79 @@
+package logger
+
+import "go.uber.org/zap/zapcore"
+
+// fieldEdit is what a rewriteCore's field func did to a field.
+type fieldEdit int
+
+const (
+	fieldKept fieldEdit = iota
+	fieldReplaced
+	fieldDropped
+)
+
+// rewriteCore edits entries and their fields before handing them to the
+// wrapped core. It is the core behind the options that scrub, reshape, or add
+// to entries, such as WithRedaction and WithFieldFromFunc.
+type rewriteCore struct {
+	zapcore.Core
+	// field, when set, is applied to every field, those added with With as
+	// well as each entry's own, returning the field to write in its place.
+	field func(zapcore.Field) (zapcore.Field, fieldEdit)
+	// entry, when set, rewrites each entry and its fields once field has run.
+	// edited reports whether field replaced or dropped any of them. fields
+	// belongs to the caller, so entry must copy it before changing it.
+	entry func(ent zapcore.Entry, fields []zapcore.Field, edited bool) (zapcore.Entry, []zapcore.Field)
+}
+
+func (c *rewriteCore) With(fields []zapcore.Field) zapcore.Core {
+	fields, _ = c.fields(fields)
+	return &rewriteCore{Core: c.Core.With(fields), field: c.field, entry: c.entry}
+}
+
+func (c *rewriteCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *rewriteCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields, edited := c.fields(fields)
+	if c.entry != nil {
+		ent, fields = c.entry(ent, fields, edited)
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// fields applies c.field to fields and reports whether any were replaced or
+// dropped. fields belongs to the caller, so it's copied before the first
+// change, and returned as is, without allocating, when nothing changes.
+func (c *rewriteCore) fields(fields []zapcore.Field) ([]zapcore.Field, bool) {
+	if c.field == nil {
+		return fields, false
+	}
+	var out []zapcore.Field
+	for i, f := range fields {
+		f, edit := c.field(f)
+		if edit == fieldKept && out == nil {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields)
+		}
+		if edit != fieldDropped {
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return fields, false
+	}
+	return out, true
+}
+
+// appendField returns fields with f added, copying fields, which belongs to
+// the caller, rather than writing into its spare capacity.
+func appendField(fields []zapcore.Field, f ...zapcore.Field) []zapcore.Field {
+	return append(fields[:len(fields):len(fields)], f...)
+}
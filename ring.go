@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// LoggedEntry is an entry kept by a RingBuffer, with the fields added to it
+// and to its logger.
+type LoggedEntry = observer.LoggedEntry
+
+// WithMemoryBuffer keeps the last n entries the logger writes in the returned
+// RingBuffer, alongside its other outputs, for crash reports or a "recent
+// logs" page. Older entries are dropped as new ones arrive. If n isn't
+// positive the RingBuffer is nil and the option fails.
+func WithMemoryBuffer(n int) (loggerOpt, *RingBuffer) {
+	if n <= 0 {
+		return func(*builder) error {
+			return fmt.Errorf("%w: memory buffer size %d must be positive", ErrInvalidOption, n)
+		}, nil
+	}
+
+	ring := &RingBuffer{entries: make([]LoggedEntry, n)}
+	return func(b *builder) error {
+		b.tees = append(b.tees, func() (zapcore.Core, error) {
+			return &teeCore{LevelEnabler: b.cfg.Level, write: ring.write}, nil
+		})
+		return nil
+	}, ring
+}
+
+// RingBuffer holds the most recent entries written by a logger built with
+// WithMemoryBuffer. It is safe for concurrent use.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []LoggedEntry
+	// next is the index the next entry is stored at, and full is set once
+	// every slot has been used.
+	next int
+	full bool
+}
+
+// Entries returns a copy of the entries held, oldest first.
+func (r *RingBuffer) Entries() []LoggedEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]LoggedEntry(nil), r.entries[:r.next]...)
+	}
+	out := make([]LoggedEntry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+func (r *RingBuffer) write(ent zapcore.Entry, fields []zapcore.Field) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = LoggedEntry{Entry: ent, Context: fields}
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return nil
+}
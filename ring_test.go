@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithMemoryBuffer(t *testing.T) {
+	opt, ring := WithMemoryBuffer(3)
+	log, buf := newBufferLogger(t, WithoutSampling(), opt)
+
+	log.Info("first")
+	if got := ring.Entries(); len(got) != 1 || got[0].Message != "first" {
+		t.Fatalf("entries = %v, want only the first", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		log.Debug("filtered")
+		log.With("k", "v").Infow("entry", "i", i)
+	}
+	entries := ring.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want the last 3", len(entries))
+	}
+	for i, e := range entries {
+		ctx := e.ContextMap()
+		if e.Message != "entry" || ctx["i"] != int64(i+2) || ctx["k"] != "v" || ctx["service"] != TestService {
+			t.Errorf("entry %d = %s %v, want entry i=%d with the logger's fields", i, e.Message, ctx, i+2)
+		}
+	}
+	if got := len(buf.lines()); got != 6 {
+		t.Errorf("got %d lines, want every entry written to the outputs too", got)
+	}
+
+	// Entries returns a copy.
+	entries[0].Message = "changed"
+	if ring.Entries()[0].Message != "entry" {
+		t.Error("changing the returned entries changed the ring")
+	}
+
+	if opt, ring := WithMemoryBuffer(0); ring != nil {
+		t.Error("WithMemoryBuffer(0) returned a ring")
+	} else if _, err := New(TestService, opt); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("zero size error = %v, want ErrInvalidOption", err)
+	}
+}
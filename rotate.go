@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const lumberjackScheme = "lumberjack"
+
+var (
+	lumberjackOnce sync.Once
+	lumberjackErr  error
+)
+
+// WithRotatingFile adds a file output that is rotated by lumberjack once it
+// reaches maxSizeMB megabytes. At most maxBackups rotated files are kept and
+// files older than maxAgeDays are removed; a zero value keeps lumberjack's
+// default for that setting. Rotated files are gzipped when compress is set.
+// The file is written in addition to the output paths, and gets every entry
+// when used with WithLevelRouting.
+func WithRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int, compress bool) loggerOpt {
+	return func(b *builder) error {
+		if maxSizeMB < 0 || maxBackups < 0 || maxAgeDays < 0 {
+			return fmt.Errorf("%w: rotating file %q: size, backups, and age must not be negative", ErrInvalidOption, path)
+		}
+
+		lumberjackOnce.Do(func() {
+			lumberjackErr = zap.RegisterSink(lumberjackScheme, newLumberjackSink)
+		})
+		if lumberjackErr != nil {
+			return lumberjackErr
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("rotating file %q: %w", path, err)
+		}
+
+		q := url.Values{}
+		q.Set("maxsize", strconv.Itoa(maxSizeMB))
+		q.Set("maxbackups", strconv.Itoa(maxBackups))
+		q.Set("maxage", strconv.Itoa(maxAgeDays))
+		q.Set("compress", strconv.FormatBool(compress))
+		u := url.URL{Scheme: lumberjackScheme, Path: abs, RawQuery: q.Encode()}
+
+		b.extraPaths = append(b.extraPaths, u.String())
+		return nil
+	}
+}
+
+// lumberjackSink adapts a lumberjack.Logger to zap.Sink. lumberjack writes
+// straight to the file so there is nothing to flush on Sync.
+type lumberjackSink struct {
+	*lumberjack.Logger
+}
+
+func (lumberjackSink) Sync() error { return nil }
+
+func newLumberjackSink(u *url.URL) (zap.Sink, error) {
+	q := u.Query()
+	l := &lumberjack.Logger{Filename: u.Path}
+
+	var err error
+	if l.MaxSize, err = strconv.Atoi(q.Get("maxsize")); err != nil {
+		return nil, fmt.Errorf("lumberjack maxsize: %w", err)
+	}
+	if l.MaxBackups, err = strconv.Atoi(q.Get("maxbackups")); err != nil {
+		return nil, fmt.Errorf("lumberjack maxbackups: %w", err)
+	}
+	if l.MaxAge, err = strconv.Atoi(q.Get("maxage")); err != nil {
+		return nil, fmt.Errorf("lumberjack maxage: %w", err)
+	}
+	if l.Compress, err = strconv.ParseBool(q.Get("compress")); err != nil {
+		return nil, fmt.Errorf("lumberjack compress: %w", err)
+	}
+
+	return lumberjackSink{l}, nil
+}
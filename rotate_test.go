@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	log, _ := newBufferLogger(t, WithRotatingFile(path, 1, 1, 0, false), WithoutSampling())
+
+	// Each line is over 1KB, so a little over a thousand fill the 1MB file.
+	msg := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		log.Info(msg)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backups int
+	for _, e := range entries {
+		if e.Name() != "app.log" && strings.HasPrefix(e.Name(), "app-") {
+			backups++
+		}
+	}
+	if backups != 1 {
+		t.Errorf("found %d backups in %v, want 1", backups, entries)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("current file: %v", err)
+	}
+}
+
+func TestWithRotatingFileInvalid(t *testing.T) {
+	if _, err := New(TestService, WithRotatingFile("app.log", -1, 0, 0, false)); err == nil {
+		t.Error("negative size was accepted")
+	}
+}
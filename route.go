@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelRoute sends entries from MinLevel through MaxLevel, inclusive, to
+// OutputPaths. The levels accept the same names as WithLevel; an empty
+// MinLevel or MaxLevel leaves that end of the range open.
+type LevelRoute struct {
+	MinLevel    string
+	MaxLevel    string
+	OutputPaths []string
+}
+
+// route is a LevelRoute with its levels parsed.
+type route struct {
+	min, max zapcore.Level
+	paths    []string
+}
+
+// WithLevelRouting sends each entry to the output paths of every route its
+// level falls in, in place of the configured output paths. For example, to
+// keep Debug and Info on stdout while copying Warn and above to stderr and a
+// file:
+//
+//	WithLevelRouting([]LevelRoute{
+//		{MaxLevel: "info", OutputPaths: []string{"stdout"}},
+//		{MinLevel: "warn", OutputPaths: []string{"stderr", "/var/log/myapp/errors.log"}},
+//	})
+//
+// Every route uses the configured encoding, and the logger's level still
+// applies on top of the routes. Outputs added by options such as
+// WithOutputWriter, WithRotatingFile, WithKafkaOutput, and WithHTTPOutput get
+// every entry, as if given a route of their own with both ends open.
+func WithLevelRouting(routes []LevelRoute) loggerOpt {
+	return func(b *builder) error {
+		if len(routes) == 0 {
+			return fmt.Errorf("%w: level routing requires at least one route", ErrInvalidOption)
+		}
+
+		parsed := make([]route, 0, len(routes))
+		for _, r := range routes {
+			rt := route{min: TraceLevel, max: zapcore.FatalLevel, paths: r.OutputPaths}
+			if r.MinLevel != "" {
+				lvl, err := b.parseLevel(r.MinLevel)
+				if err != nil {
+					return err
+				}
+				rt.min = lvl
+			}
+			if r.MaxLevel != "" {
+				lvl, err := b.parseLevel(r.MaxLevel)
+				if err != nil {
+					return err
+				}
+				rt.max = lvl
+			}
+			if rt.min > rt.max {
+				return fmt.Errorf("%w: level route %q to %q is empty", ErrInvalidOption, r.MinLevel, r.MaxLevel)
+			}
+			if len(rt.paths) == 0 {
+				return fmt.Errorf("%w: level route %q to %q has no output paths", ErrInvalidOption, r.MinLevel, r.MaxLevel)
+			}
+			parsed = append(parsed, rt)
+		}
+
+		b.routes = parsed
+		return nil
+	}
+}
+
+// routeCore builds the tee of one core per route, opening the routes' output
+// paths. It replaces the core zap.Config builds from the output paths.
+func (b *builder) routeCore() (zapcore.Core, error) {
+	enc := b.newEncoder()
+	cores := make([]zapcore.Core, 0, len(b.routes))
+	for _, r := range b.routes {
+		ws, err := b.openOutputs(r.paths)
+		if err != nil {
+			return nil, err
+		}
+		core := zapcore.NewCore(enc.Clone(), ws, routeEnabler{route: r, level: b.cfg.Level})
+		cores = append(cores, filteredCore{core})
+	}
+	return zapcore.NewTee(cores...), nil
+}
+
+// routeEnabler enables the levels of a route that the logger's level allows.
+type routeEnabler struct {
+	route
+	level zapcore.LevelEnabler
+}
+
+func (e routeEnabler) Enabled(l zapcore.Level) bool {
+	return l >= e.min && l <= e.max && e.level.Enabled(l)
+}
+
+// filteredCore drops entries its core isn't enabled for in Write as well as in
+// Check, since wrapping cores may write to a tee without checking each member.
+type filteredCore struct {
+	zapcore.Core
+}
+
+func (c filteredCore) With(fields []zapcore.Field) zapcore.Core {
+	return filteredCore{c.Core.With(fields)}
+}
+
+func (c filteredCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c filteredCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithLevelRouting(t *testing.T) {
+	dir := t.TempDir()
+	stdout := filepath.Join(dir, "stdout.log")
+	stderr := filepath.Join(dir, "stderr.log")
+	errorsFile := filepath.Join(dir, "errors.log")
+
+	log, buf := newBufferLogger(t, WithLevel("debug"), WithLevelRouting([]LevelRoute{
+		{MaxLevel: "info", OutputPaths: []string{stdout}},
+		{MinLevel: "warn", OutputPaths: []string{stderr, errorsFile}},
+	}))
+	log.Debug("debugging")
+	log.Error("failed")
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	for path, want := range map[string][]string{
+		stdout:     {"debugging"},
+		stderr:     {"failed"},
+		errorsFile: {"failed"},
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != len(want) || !strings.Contains(lines[0], want[0]) {
+			t.Errorf("%s = %q, want only %q", filepath.Base(path), data, want)
+		}
+	}
+
+	// Outputs added by options get every entry.
+	if lines := decodeLines(t, buf); len(lines) != 2 {
+		t.Errorf("extra output got %q, want both entries", buf.String())
+	}
+}
+
+func TestWithLevelRoutingInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []LevelRoute
+		want   error
+	}{
+		{"no routes", nil, ErrInvalidOption},
+		{"no paths", []LevelRoute{{MinLevel: "warn"}}, ErrInvalidOption},
+		{"empty range", []LevelRoute{{MinLevel: "error", MaxLevel: "info", OutputPaths: []string{"stdout"}}}, ErrInvalidOption},
+		{"unknown level", []LevelRoute{{MinLevel: "loud", OutputPaths: []string{"stdout"}}}, ErrUnknownLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(TestService, WithLevelRouting(tt.routes)); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// WithLevelSampling limits sampling to entries below level, so entries at or
+// above it, such as warnings and errors, are never dropped however often they
+// repeat. Entries below level are sampled as configured by WithSampling; the
+// option has no effect once sampling is disabled.
+func WithLevelSampling(level string) loggerOpt {
+	return func(b *builder) error {
+		lvl, err := b.parseLevel(level)
+		if err != nil {
+			return err
+		}
+		b.sampleBelow = &lvl
+		return nil
+	}
+}
+
+// WithSamplingHook calls hook with every decision the sampler makes, so the
+// entries it drops (zapcore.LogDropped) can be counted, for example. hook runs
+// on the logging goroutine for every entry that reaches the sampler, so keep
+// it cheap. It isn't called once sampling is disabled.
+func WithSamplingHook(hook func(zapcore.Entry, zapcore.SamplingDecision)) loggerOpt {
+	return func(b *builder) error {
+		b.samplingHook = hook
+		return nil
+	}
+}
+
+// levelSampler sends entries below a level through a sampler and the rest
+// straight to the core.
+type levelSampler struct {
+	zapcore.Core
+	sampled zapcore.Core
+	below   zapcore.Level
+}
+
+func (c *levelSampler) With(fields []zapcore.Field) zapcore.Core {
+	return &levelSampler{Core: c.Core.With(fields), sampled: c.sampled.With(fields), below: c.below}
+}
+
+func (c *levelSampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.below {
+		return c.sampled.Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithLevelSampling(t *testing.T) {
+	log, buf := newBufferLogger(t, WithSampling(2, 0), WithLevelSampling("warn"))
+	for i := 0; i < 100; i++ {
+		log.Info("flood")
+		log.Error("failing")
+	}
+
+	counts := map[string]int{}
+	for _, line := range decodeLines(t, buf) {
+		counts[line["level"].(string)]++
+	}
+	if counts["info"] != 2 || counts["error"] != 100 {
+		t.Errorf("counts = %v, want 2 info and every one of the 100 errors", counts)
+	}
+}
+
+func TestWithSamplingHook(t *testing.T) {
+	var dropped atomic.Int64
+	hook := func(_ zapcore.Entry, dec zapcore.SamplingDecision) {
+		if dec&zapcore.LogDropped != 0 {
+			dropped.Add(1)
+		}
+	}
+	log, buf := newBufferLogger(t, WithSampling(1, 0), WithSamplingHook(hook))
+	for i := 0; i < 10; i++ {
+		log.Info("again")
+	}
+	if got := len(buf.lines()); got != 1 {
+		t.Errorf("got %d lines, want 1", got)
+	}
+	if n := dropped.Load(); n != 9 {
+		t.Errorf("hook saw %d drops, want 9", n)
+	}
+}
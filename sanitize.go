@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithSanitizeControlChars escapes control characters such as NUL, backspace,
+// and escape in the message, the logger name, and string fields, so they can't
+// corrupt terminals or log viewers. Each is replaced by its Go escape, like
+// `\x00` or `\x1b`. Tabs are kept, and newlines and carriage returns are left
+// to WithNewlineEscaping, with which this option can be combined.
+func WithSanitizeControlChars() loggerOpt {
+	return func(b *builder) error {
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, field: sanitizeField, entry: sanitizeEntry}
+		})
+		return nil
+	}
+}
+
+// sanitizeField escapes control characters in string fields.
+func sanitizeField(f zapcore.Field) (zapcore.Field, fieldEdit) {
+	if f.Type != zapcore.StringType {
+		return f, fieldKept
+	}
+	s := sanitize(f.String)
+	if s == f.String {
+		return f, fieldKept
+	}
+	f.String = s
+	return f, fieldReplaced
+}
+
+// sanitizeEntry escapes control characters in the message and logger name.
+func sanitizeEntry(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+	ent.Message = sanitize(ent.Message)
+	ent.LoggerName = sanitize(ent.LoggerName)
+	return ent, fields
+}
+
+// sanitize returns s with control characters escaped. s is returned as is,
+// without allocating, when it has none.
+func sanitize(s string) string {
+	if strings.IndexFunc(s, isUnsafeControl) < 0 {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) + 8)
+	for _, r := range s {
+		switch {
+		case !isUnsafeControl(r):
+			sb.WriteRune(r)
+		case r < 0x80:
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		default:
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		}
+	}
+	return sb.String()
+}
+
+// isUnsafeControl reports whether r is a control character sanitize escapes.
+func isUnsafeControl(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return false
+	}
+	return unicode.IsControl(r)
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithSanitizeControlChars(t *testing.T) {
+	log, buf := newBufferLogger(t, WithEncoding("console"), WithSanitizeControlChars(), WithNewlineEscaping())
+	log.Named("db\x00").Infow("bad\x00input\x1b[31m\tred\nnext", "user", "bob\x08\x1b]0;x", "n", 1)
+
+	out := buf.String()
+	if n := strings.Count(out, "\n"); n != 1 {
+		t.Fatalf("got %d lines, want 1: %q", n, out)
+	}
+	if strings.ContainsAny(out, "\x00\x08\x1b") {
+		t.Errorf("output %q still has control characters", out)
+	}
+	for _, want := range []string{`bad\x00input\x1b[31m` + "\t" + `red\nnext`, `db\x00`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q is missing %q", out, want)
+		}
+	}
+
+	// String fields are escaped too, and other fields left alone.
+	log, buf = newBufferLogger(t, WithSanitizeControlChars())
+	log.Infow("login", "user", "bob\x08\x1b]0;x", "n", 1)
+	if line := decodeLines(t, buf)[0]; line["user"] != `bob\x08\x1b]0;x` || line["n"] != float64(1) {
+		t.Errorf("line = %v, want user escaped and n kept", line)
+	}
+
+	// Clean strings are passed through as is.
+	if s := "plain\ttext ünïcode"; sanitize(s) != s {
+		t.Errorf("sanitize(%q) = %q", s, sanitize(s))
+	}
+}
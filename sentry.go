@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// sentryQueueSize bounds the events waiting to be sent to Sentry. Events
+	// are dropped once it fills rather than blocking the caller.
+	sentryQueueSize = 256
+	// sentryFlushTimeout bounds how long Sync waits for Sentry.
+	sentryFlushTimeout = 2 * time.Second
+)
+
+var errSentryFlush = errors.New("sentry: timed out flushing events")
+
+// WithSentry reports entries at or above minLevel to Sentry through hub, or
+// the current hub if hub is nil. The entry's fields are attached as extras
+// and error fields as exceptions. Events are queued and sent from a
+// background goroutine so logging never waits on the network; Sync waits for
+// the queue to drain and for the hub to flush, and Shutdown stops the
+// goroutine.
+func WithSentry(hub *sentry.Hub, minLevel string) loggerOpt {
+	return func(b *builder) error {
+		lvl, err := b.parseLevel(minLevel)
+		if err != nil {
+			return err
+		}
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+
+		b.tees = append(b.tees, func() (zapcore.Core, error) {
+			s := newSentrySender(hub)
+			b.onShutdown(s.close)
+			return &teeCore{LevelEnabler: lvl, write: s.write, sync: s.sync}, nil
+		})
+		return nil
+	}
+}
+
+// sentrySender delivers events to the hub from a single goroutine until it's
+// closed.
+type sentrySender struct {
+	hub     *sentry.Hub
+	events  chan *sentry.Event
+	pending inflight
+
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+}
+
+func newSentrySender(hub *sentry.Hub) *sentrySender {
+	s := &sentrySender{
+		hub:    hub,
+		events: make(chan *sentry.Event, sentryQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *sentrySender) run() {
+	for {
+		select {
+		case ev := <-s.events:
+			s.hub.CaptureEvent(ev)
+			s.pending.done()
+		case <-s.done:
+			// Nothing is queued once closed is set, so the events left can
+			// be counted off.
+			for {
+				select {
+				case <-s.events:
+					s.pending.done()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *sentrySender) send(ev *sentry.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.pending.add()
+	select {
+	case s.events <- ev:
+	default:
+		s.pending.done()
+	}
+}
+
+// close stops the goroutine, discarding any events still queued. Shutdown
+// syncs the logger first, so none are.
+func (s *sentrySender) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	return nil
+}
+
+func (s *sentrySender) flush() error {
+	s.pending.wait()
+	if !s.hub.Flush(sentryFlushTimeout) {
+		return errSentryFlush
+	}
+	return nil
+}
+
+// write queues an event for the entry.
+func (s *sentrySender) write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ev := sentry.NewEvent()
+	ev.Level = sentryLevel(ent.Level)
+	ev.Message = ent.Message
+	ev.Timestamp = ent.Time
+	ev.Logger = ent.LoggerName
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType {
+			if err, ok := f.Interface.(error); ok {
+				ev.Exception = append(ev.Exception, sentry.Exception{
+					Type:  reflect.TypeOf(err).String(),
+					Value: err.Error(),
+				})
+			}
+		}
+		f.AddTo(enc)
+	}
+	if ent.Caller.Defined {
+		enc.Fields["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		enc.Fields["stacktrace"] = ent.Stack
+	}
+	ev.Extra = enc.Fields
+
+	s.send(ev)
+	return nil
+}
+
+func (s *sentrySender) sync() error {
+	if err := s.flush(); err != nil {
+		return fmt.Errorf("%w after %s", err, sentryFlushTimeout)
+	}
+	return nil
+}
+
+func sentryLevel(l zapcore.Level) sentry.Level {
+	switch {
+	case l >= zapcore.DPanicLevel:
+		return sentry.LevelFatal
+	case l >= zapcore.ErrorLevel:
+		return sentry.LevelError
+	case l >= zapcore.WarnLevel:
+		return sentry.LevelWarning
+	case l >= zapcore.InfoLevel:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
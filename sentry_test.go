@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// fakeTransport records the events a Sentry client sends.
+type fakeTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *fakeTransport) Configure(sentry.ClientOptions) {}
+
+func (t *fakeTransport) Flush(time.Duration) bool { return true }
+
+func (t *fakeTransport) SendEvent(ev *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, ev)
+}
+
+func (t *fakeTransport) sent() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// newFakeHub returns a hub whose events are recorded by the returned transport.
+func newFakeHub(t *testing.T) (*sentry.Hub, *fakeTransport) {
+	t.Helper()
+	transport := &fakeTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), transport
+}
+
+func TestWithSentry(t *testing.T) {
+	hub, transport := newFakeHub(t)
+	log, buf := newBufferLogger(t, WithSentry(hub, "error"))
+	log.Warn("not reported")
+	log.With("order", 42).Errorw("payment failed", "error", errors.New("card declined"))
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	if got := len(buf.lines()); got != 2 {
+		t.Errorf("got %d lines, want both entries written to the output too", got)
+	}
+	events := transport.sent()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	ev := events[0]
+	if ev.Level != sentry.LevelError || ev.Message != "payment failed" {
+		t.Errorf("event = %s %q, want error %q", ev.Level, ev.Message, "payment failed")
+	}
+	if ev.Extra["order"] != int64(42) || ev.Extra["service"] != TestService {
+		t.Errorf("extras = %v, want order and service", ev.Extra)
+	}
+	if len(ev.Exception) != 1 || ev.Exception[0].Value != "card declined" {
+		t.Errorf("exceptions = %+v, want the logged error", ev.Exception)
+	}
+
+	if _, err := New(TestService, WithSentry(hub, "loud")); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("unknown level error = %v, want ErrUnknownLevel", err)
+	}
+}
+
+func TestWithSentryAfterShutdown(t *testing.T) {
+	hub, transport := newFakeHub(t)
+	log, _ := newBufferLogger(t, WithSentry(hub, "error"))
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	log.Error("too late")
+	_ = log.Sync()
+	if got := len(transport.sent()); got != 0 {
+		t.Errorf("got %d events after Shutdown, want 0", got)
+	}
+}
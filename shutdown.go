@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// closers holds, for each logger returned by New, the functions that stop the
+// background work started by its options.
+var closers sync.Map // *zap.SugaredLogger -> []func() error
+
+// registry holds every logger returned by New until it's unregistered, so
+// FlushAll can sync them.
+var registry sync.Map // *zap.SugaredLogger -> struct{}
+
+// onShutdown registers fn to be called by Shutdown once the logger is built.
+func (b *builder) onShutdown(fn func() error) {
+	b.closers = append(b.closers, fn)
+}
+
+// close calls the closers registered so far, for a logger that failed to
+// build. Their errors are dropped in favour of the one that stopped the build.
+func (b *builder) close() {
+	for _, fn := range b.closers {
+		_ = fn()
+	}
+}
+
+// Sync flushes log, ignoring the errors returned when syncing stdout or
+// stderr attached to a terminal or pipe, which can't be synced. Errors from
+// every other output are returned.
+func Sync(log *zap.SugaredLogger) error {
+	return withoutBenignSyncErrors(log.Desugar().Sync())
+}
+
+// Shutdown flushes log like Sync, then stops the buffers and background
+// senders created for it by options such as WithBuffer, WithHTTPOutput, and
+// WithKafkaOutput, flushing them one last time. Only call Shutdown on a
+// logger returned by New, once nothing will log to it or to loggers derived
+// from it again, typically deferred in main.
+func Shutdown(log *zap.SugaredLogger) error {
+	Unregister(log)
+	errs := []error{Sync(log)}
+	if fns, ok := closers.LoadAndDelete(log); ok {
+		for _, fn := range fns.([]func() error) {
+			errs = append(errs, withoutBenignSyncErrors(fn()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FlushAll syncs every logger built by New, NewWithLevel, or NewDevelopment
+// that hasn't been shut down or unregistered, ignoring the same errors as
+// Sync and returning the rest together.
+func FlushAll() error {
+	var errs []error
+	registry.Range(func(log, _ any) bool {
+		errs = append(errs, Sync(log.(*zap.SugaredLogger)))
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// Unregister removes log from the loggers FlushAll syncs, so short-lived
+// loggers can be garbage collected. Shutdown unregisters the logger itself.
+func Unregister(log *zap.SugaredLogger) {
+	registry.Delete(log)
+}
+
+// withoutBenignSyncErrors removes the errors isBenignSyncError accepts from
+// err, which may combine several.
+func withoutBenignSyncErrors(err error) error {
+	var errs []error
+	for _, err := range multierr.Errors(err) {
+		if !isBenignSyncError(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithPeriodicSync syncs the logger every interval from a background
+// goroutine, so entries held by WithBuffer and similar outputs don't wait
+// long to be written. Errors, other than those Sync ignores, are printed to
+// stderr. Shutdown stops the goroutine.
+func WithPeriodicSync(interval time.Duration) loggerOpt {
+	return func(b *builder) error {
+		if interval <= 0 {
+			return fmt.Errorf("%w: sync interval %s must be positive", ErrInvalidOption, interval)
+		}
+		b.syncInterval = interval
+		return nil
+	}
+}
+
+// syncEvery syncs log every interval until the returned function is called.
+func syncEvery(log *zap.SugaredLogger, interval time.Duration) func() error {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := Sync(log); err != nil {
+					fmt.Fprintf(os.Stderr, "logger: periodic sync: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() error {
+		close(done)
+		<-stopped
+		return nil
+	}
+}
+
+// isBenignSyncError reports whether err comes from syncing stdout or stderr,
+// which fails with EINVAL or ENOTSUP (ENOTTY on some systems) when it isn't a
+// regular file.
+func isBenignSyncError(err error) bool {
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) {
+		return false
+	}
+	if pathErr.Path != "/dev/stdout" && pathErr.Path != "/dev/stderr" {
+		return false
+	}
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.ENOTTY)
+}
+
+// inflight counts the work handed to a background goroutine that it has yet
+// to finish, so Sync can wait for it. Unlike a sync.WaitGroup, work may be
+// added while another goroutine waits.
+type inflight struct {
+	mu   sync.Mutex
+	cond sync.Cond
+	n    int
+}
+
+func (f *inflight) add() {
+	f.mu.Lock()
+	f.n++
+	f.mu.Unlock()
+}
+
+func (f *inflight) done() {
+	f.mu.Lock()
+	f.n--
+	if f.n == 0 {
+		f.cond.Broadcast()
+	}
+	f.mu.Unlock()
+}
+
+// wait blocks until no work is left.
+func (f *inflight) wait() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cond.L == nil {
+		f.cond.L = &f.mu
+	}
+	for f.n > 0 {
+		f.cond.Wait()
+	}
+}
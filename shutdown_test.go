@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// syncErrWriter discards writes and fails Sync with err.
+type syncErrWriter struct{ err error }
+
+func (w syncErrWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func (w syncErrWriter) Sync() error { return w.err }
+
+func TestSync(t *testing.T) {
+	stdout := &fs.PathError{Op: "sync", Path: "/dev/stdout", Err: syscall.EINVAL}
+	stderr := &fs.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.ENOTTY}
+	file := &fs.PathError{Op: "sync", Path: "/var/log/app.log", Err: syscall.EINVAL}
+	disk := &fs.PathError{Op: "sync", Path: "/dev/stdout", Err: syscall.EIO}
+
+	tests := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{"ok", nil, nil},
+		{"stdout", stdout, nil},
+		{"stderr", stderr, nil},
+		{"file", file, []error{file}},
+		{"real stdout error", disk, []error{disk}},
+		{"combined", multierr.Combine(stdout, file, stderr), []error{file}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncErrWriter{tt.err}, zap.InfoLevel)
+			err := Sync(zap.New(core).Sugar())
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Sync = %v, want nil", err)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("Sync = %v, want it to include %v", err, want)
+				}
+			}
+			for _, benign := range []error{stdout, stderr} {
+				if errors.Is(err, benign) {
+					t.Errorf("Sync = %v, want %v ignored", err, benign)
+				}
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var closed int
+	log, buf := newBufferLogger(t, func(b *builder) error {
+		b.onShutdown(func() error {
+			closed++
+			return nil
+		})
+		return nil
+	}, WithBuffer(0, 0))
+	log.Info("buffered")
+
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if closed != 1 {
+		t.Errorf("closers ran %d times, want 1", closed)
+	}
+	if got := len(buf.lines()); got != 1 {
+		t.Errorf("got %d lines, want the buffer flushed", got)
+	}
+	// The cleanup registered by newBufferLogger shuts it down again.
+}
+
+func TestWithPeriodicSync(t *testing.T) {
+	log, buf := newBufferLogger(t, WithoutSampling(), WithBuffer(64*1024, time.Hour), WithPeriodicSync(20*time.Millisecond))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			log.Infow("concurrent", "i", i)
+		}
+	}()
+	<-done
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(buf.lines()) < 100 && time.Now().Before(deadline) {
+		waitABit()
+	}
+	if got := len(buf.lines()); got != 100 {
+		t.Errorf("got %d lines without calling Sync, want 100", got)
+	}
+
+	if _, err := New(TestService, WithPeriodicSync(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("zero interval error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	buffered, bufferedOut := newBufferLogger(t, WithBuffer(0, time.Hour))
+	plain, plainOut := newBufferLogger(t)
+	failed := &fs.PathError{Op: "sync", Path: "/var/log/app.log", Err: syscall.EIO}
+	failing, err := New(TestService, WithOutputPaths(), WithOutputWriter(syncErrWriter{failed}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { Unregister(failing) })
+
+	buffered.Info("held")
+	plain.Info("written")
+	if got := len(bufferedOut.lines()); got != 0 {
+		t.Fatalf("got %d lines before FlushAll, want them held by the buffer", got)
+	}
+
+	if err := FlushAll(); !errors.Is(err, failed) {
+		t.Errorf("FlushAll = %v, want it to include %v", err, failed)
+	}
+	if len(bufferedOut.lines()) != 1 || len(plainOut.lines()) != 1 {
+		t.Errorf("got %d and %d lines, want both loggers flushed", len(bufferedOut.lines()), len(plainOut.lines()))
+	}
+
+	Unregister(failing)
+	if err := FlushAll(); errors.Is(err, failed) {
+		t.Errorf("FlushAll = %v, want the unregistered logger skipped", err)
+	}
+
+	// Shutdown unregisters the logger too.
+	if err := Shutdown(plain); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, ok := registry.Load(plain); ok {
+		t.Error("Shutdown left the logger registered")
+	}
+}
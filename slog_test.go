@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestNewSlogHandler(t *testing.T) {
+	log, buf := newBufferLogger(t, WithLevel("debug"))
+	s := slog.New(NewSlogHandler(log)).With("request", 7).WithGroup("http")
+
+	s.Debug("skipped by level", "k", 1)
+	s.Warn("served",
+		"status", 200,
+		slog.Group("client", "ip", "10.0.0.1"),
+		"err", errors.New("slow"),
+	)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	line := lines[1]
+	if line["level"] != "warn" || line["msg"] != "served" || line["service"] != TestService {
+		t.Errorf("line = %v", line)
+	}
+	if line["request"] != float64(7) {
+		t.Errorf("request = %v, want 7", line["request"])
+	}
+	group, ok := line["http"].(map[string]any)
+	if !ok {
+		t.Fatalf("http group = %v, want an object", line["http"])
+	}
+	if group["status"] != float64(200) || group["err"] != "slow" {
+		t.Errorf("http group = %v", group)
+	}
+	if client, _ := group["client"].(map[string]any); client["ip"] != "10.0.0.1" {
+		t.Errorf("client group = %v", group["client"])
+	}
+	if _, ok := line["caller"]; !ok {
+		t.Error("caller is missing")
+	}
+}
+
+func TestNewSlogHandlerLevel(t *testing.T) {
+	log, buf := newBufferLogger(t)
+	s := slog.New(NewSlogHandler(log))
+
+	s.Debug("hidden")
+	s.Info("shown")
+
+	if lines := decodeLines(t, buf); len(lines) != 1 || lines[0]["msg"] != "shown" {
+		t.Errorf("got %q, want only the Info line", buf.String())
+	}
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithStackFilter removes frames from stack traces whose function starts with
+// any of skipPrefixes, such as "go.uber.org/zap" or "runtime.", so traces
+// begin at the application code. Each prefix is matched against the fully
+// qualified function name, e.g. "github.com/org/app/db.(*Store).Get".
+func WithStackFilter(skipPrefixes []string) loggerOpt {
+	return func(b *builder) error {
+		if len(skipPrefixes) == 0 {
+			return nil
+		}
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, entry: stackFilter(skipPrefixes).entry}
+		})
+		return nil
+	}
+}
+
+// stackFilter removes the frames of functions starting with any of its
+// prefixes from stack traces.
+type stackFilter []string
+
+func (c stackFilter) entry(ent zapcore.Entry, fields []zapcore.Field, _ bool) (zapcore.Entry, []zapcore.Field) {
+	if ent.Stack != "" {
+		ent.Stack = c.filter(ent.Stack)
+	}
+	return ent, fields
+}
+
+// filter drops the skipped frames from stack, which zap writes as a function
+// line followed by a tab-indented file and line for each frame.
+func (c stackFilter) filter(stack string) string {
+	lines := strings.Split(stack, "\n")
+	kept := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "\t") {
+			kept = append(kept, line)
+			continue
+		}
+		if c.skipped(line) {
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				i++
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+func (c stackFilter) skipped(function string) bool {
+	for _, prefix := range c {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
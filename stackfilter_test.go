@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithStackFilter(t *testing.T) {
+	log, buf := newBufferLogger(t, WithStacktrace("error"), WithStackFilter([]string{"testing.", "runtime."}))
+	log.Error("boom")
+
+	stack, _ := decodeLines(t, buf)[0]["stacktrace"].(string)
+	if !strings.Contains(stack, "TestWithStackFilter") {
+		t.Errorf("stacktrace = %q, want the test's own frame", stack)
+	}
+	if strings.Contains(stack, "testing.tRunner") || strings.Contains(stack, "runtime.goexit") {
+		t.Errorf("stacktrace = %q, want the testing and runtime frames removed", stack)
+	}
+
+	stack = strings.Join([]string{
+		"github.com/funayman/logger.(*rewriteCore).Write",
+		"\t/src/logger/rewrite.go:45",
+		"main.handle",
+		"\t/src/app/main.go:20",
+		"github.com/funayman/logger.Trace",
+		"\t/src/logger/logger.go:300",
+		"main.main",
+		"\t/src/app/main.go:10",
+	}, "\n")
+	want := "main.handle\n\t/src/app/main.go:20\nmain.main\n\t/src/app/main.go:10"
+	if got := stackFilter([]string{"github.com/funayman/logger."}).filter(stack); got != want {
+		t.Errorf("filter = %q, want %q", got, want)
+	}
+}
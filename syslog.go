@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogFacility is the syslog facility entries are reported under.
+type SyslogFacility int
+
+// The facilities defined by RFC 5424.
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// syslogSockets are tried in order when no network is given.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+const (
+	// syslogDialTimeout bounds each attempt to connect to the daemon, since
+	// writes wait on it.
+	syslogDialTimeout = 5 * time.Second
+	// After a failed redial, entries are dropped without dialing again for
+	// syslogRedialMin, doubling up to syslogRedialMax while the daemon stays
+	// unreachable.
+	syslogRedialMin = 100 * time.Millisecond
+	syslogRedialMax = 30 * time.Second
+)
+
+// dialSyslog is replaced in tests to watch the output redial.
+var dialSyslog = func(network, addr string) (net.Conn, error) {
+	d := net.Dialer{Timeout: syslogDialTimeout}
+	return d.Dial(network, addr)
+}
+
+// WithSyslog adds an output that sends entries to a syslog daemon as RFC 5424
+// messages under the user facility. network and addr are passed to net.Dial;
+// leave both empty to use the local syslog socket. tag is the APP-NAME of each
+// message. See WithSyslogFacility to choose another facility.
+func WithSyslog(network, addr, tag string) loggerOpt {
+	return WithSyslogFacility(network, addr, tag, FacilityUser)
+}
+
+// WithSyslogFacility behaves like WithSyslog but reports entries under
+// facility. Each entry's level is mapped to a syslog severity, and the entry
+// itself, encoded as configured by the other options, is the message body.
+// The connection is made when the logger is built and closed by Shutdown; a
+// broken connection is redialed before the entry is dropped, backing off
+// while the daemon stays unreachable.
+func WithSyslogFacility(network, addr, tag string, facility SyslogFacility) loggerOpt {
+	return func(b *builder) error {
+		if facility < FacilityKern || facility > FacilityLocal7 {
+			return fmt.Errorf("%w: unknown syslog facility %d", ErrInvalidOption, facility)
+		}
+		if tag == "" {
+			tag = "-"
+		}
+
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "-"
+		}
+
+		b.tees = append(b.tees, func() (zapcore.Core, error) {
+			w := &syslogWriter{
+				network:  network,
+				addr:     addr,
+				facility: facility,
+				host:     host,
+				tag:      tag,
+				pid:      strconv.Itoa(os.Getpid()),
+			}
+			if err := w.connect(); err != nil {
+				return nil, err
+			}
+			b.onShutdown(w.close)
+			return &teeCore{LevelEnabler: b.cfg.Level, enc: b.newEncoder(), encoded: w.writeEntry}, nil
+		})
+		return nil
+	}
+}
+
+var errSyslogClosed = errors.New("syslog: output closed")
+
+// syslogWriter sends messages over a connection that is redialed when a
+// write fails, until it's closed.
+type syslogWriter struct {
+	network, addr string
+	// facility, host, tag, and pid fill in each message's header.
+	facility SyslogFacility
+	host     string
+	tag      string
+	pid      string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+	// redialAt is when write may next dial after a failed redial, and
+	// backoff how long it waited last.
+	redialAt time.Time
+	backoff  time.Duration
+}
+
+func (w *syslogWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *syslogWriter) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	if w.network != "" {
+		conn, err := dialSyslog(w.network, w.addr)
+		if err != nil {
+			return fmt.Errorf("syslog: %w", err)
+		}
+		w.conn = conn
+		return nil
+	}
+
+	var errs []error
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := dialSyslog(network, path)
+			if err == nil {
+				w.conn = conn
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	return fmt.Errorf("syslog: no local socket: %w", errors.Join(errs...))
+}
+
+// write sends msg, reconnecting and trying once more if the connection broke
+// and the last redial didn't fail too recently.
+func (w *syslogWriter) write(msg []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return errSyslogClosed
+	}
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return nil
+		}
+	}
+	if now := time.Now(); now.Before(w.redialAt) {
+		return fmt.Errorf("syslog: not connected, redialing in %s", w.redialAt.Sub(now).Round(time.Millisecond))
+	}
+	if err := w.connect(); err != nil {
+		w.backoff = min(max(2*w.backoff, syslogRedialMin), syslogRedialMax)
+		w.redialAt = time.Now().Add(w.backoff)
+		return err
+	}
+	w.backoff, w.redialAt = 0, time.Time{}
+	return w.send(msg)
+}
+
+// send writes msg, framing it with its length on stream connections as
+// RFC 6587 requires. The caller must hold w.mu.
+func (w *syslogWriter) send(msg []byte) error {
+	if _, ok := w.conn.(*net.TCPConn); ok {
+		if _, err := fmt.Fprintf(w.conn, "%d ", len(msg)); err != nil {
+			return err
+		}
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// writeEntry sends the encoded entry as an RFC 5424 message.
+func (w *syslogWriter) writeEntry(ent zapcore.Entry, line []byte) error {
+	pri := int(w.facility)*8 + syslogSeverity(ent.Level)
+	msg := fmt.Appendf(nil, "<%d>1 %s %s %s %s - - ",
+		pri, ent.Time.UTC().Format(time.RFC3339Nano), w.host, w.tag, w.pid)
+	msg = append(msg, bytes.TrimRight(line, "\r\n")...)
+
+	return w.write(msg)
+}
+
+// syslogSeverity maps a level to the numeric severity used by syslog and the
+// formats derived from it.
+func syslogSeverity(l zapcore.Level) int {
+	switch {
+	case l >= zapcore.FatalLevel:
+		return 0 // emergency
+	case l >= zapcore.PanicLevel:
+		return 1 // alert
+	case l >= zapcore.DPanicLevel:
+		return 2 // critical
+	case l >= zapcore.ErrorLevel:
+		return 3 // error
+	case l >= zapcore.WarnLevel:
+		return 4 // warning
+	case l >= zapcore.InfoLevel:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
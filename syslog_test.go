@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	log, _ := newBufferLogger(t, WithSyslogFacility("udp", conn.LocalAddr().String(), "app", FacilityLocal0))
+	log.Errorw("disk failed", "disk", "sda")
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64*1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no message received: %v", err)
+	}
+	msg := string(buf[:n])
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	parts := strings.SplitN(msg, " ", 8)
+	if len(parts) != 8 {
+		t.Fatalf("message %q doesn't have an RFC 5424 header", msg)
+	}
+	host, _ := os.Hostname()
+	// local0 (16) * 8 + error (3)
+	if parts[0] != "<131>1" || parts[2] != host || parts[3] != "app" || parts[4] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("header = %q, want PRI 131, version 1, this host, app and this PID", parts[:5])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, parts[1]); err != nil {
+		t.Errorf("timestamp %q: %v", parts[1], err)
+	}
+	if parts[5] != "-" || parts[6] != "-" {
+		t.Errorf("MSGID and STRUCTURED-DATA = %q %q, want nil values", parts[5], parts[6])
+	}
+	var line map[string]any
+	if err := json.Unmarshal([]byte(parts[7]), &line); err != nil {
+		t.Fatalf("body %q isn't JSON: %v", parts[7], err)
+	}
+	if line["msg"] != "disk failed" || line["disk"] != "sda" {
+		t.Errorf("body = %v, want the entry", line)
+	}
+
+	if _, err := New(TestService, WithSyslogFacility("udp", conn.LocalAddr().String(), "app", 24)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("unknown facility error = %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithSyslogReconnects(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	conns := make(chan net.Conn, 4)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+
+	log, _ := newBufferLogger(t, WithSyslog("tcp", l.Addr().String(), "app"))
+	first := <-conns
+	log.Info("before")
+	// Stream messages are framed with their length.
+	r := bufio.NewReader(first)
+	size, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(size))
+	msg := make([]byte, n)
+	if _, err := r.Read(msg); err != nil || !strings.HasPrefix(string(msg), "<14>1 ") {
+		t.Fatalf("message = %q, %v; want a user.info message", msg, err)
+	}
+	first.Close()
+
+	// The first writes after the server hangs up may still succeed locally,
+	// so keep logging until the output redials.
+	for i := 0; i < 50; i++ {
+		log.Info("after")
+		select {
+		case c := <-conns:
+			c.Close()
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	t.Error("the output didn't reconnect after the connection broke")
+}
+
+func TestSyslogRedialBackoff(t *testing.T) {
+	var dials int
+	orig := dialSyslog
+	dialSyslog = func(network, addr string) (net.Conn, error) {
+		dials++
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { dialSyslog = orig })
+
+	w := &syslogWriter{network: "tcp", addr: "127.0.0.1:514"}
+	for i := 0; i < 5; i++ {
+		if err := w.write([]byte("lost")); err == nil {
+			t.Fatal("write succeeded without a connection")
+		}
+	}
+	if dials != 1 || w.backoff != syslogRedialMin {
+		t.Fatalf("dialed %d times with backoff %s, want once then %s", dials, w.backoff, syslogRedialMin)
+	}
+
+	// Once the backoff has passed, the next write redials and the wait doubles.
+	w.redialAt = time.Now()
+	_ = w.write([]byte("lost"))
+	if dials != 2 || w.backoff != 2*syslogRedialMin {
+		t.Errorf("dialed %d times with backoff %s, want twice then %s", dials, w.backoff, 2*syslogRedialMin)
+	}
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// tailQueueSize bounds the entries waiting to be sent to each TailHandler
+// client. Entries are dropped for a client once its queue fills rather than
+// blocking the logger.
+const tailQueueSize = 256
+
+// tails holds the hub of each logger built with WithTail.
+var tails sync.Map // *zap.SugaredLogger -> *tailHub
+
+// WithTail lets TailHandler stream the logger's entries to HTTP clients.
+// Entries are encoded as JSON, whatever the logger's encoding, and only while
+// a client is connected. Shutdown disconnects every client.
+func WithTail() loggerOpt {
+	return func(b *builder) error {
+		if b.tail != nil {
+			return nil
+		}
+		hub := &tailHub{clients: make(map[chan []byte]struct{}), done: make(chan struct{})}
+		b.tail = hub
+		b.tees = append(b.tees, func() (zapcore.Core, error) {
+			enc := b.cfg.EncoderConfig
+			enc.LineEnding = zapcore.DefaultLineEnding
+			return &teeCore{
+				LevelEnabler: b.cfg.Level,
+				enc:          zapcore.NewJSONEncoder(enc),
+				encoded:      hub.write,
+				idle:         hub.idle,
+			}, nil
+		})
+		return nil
+	}
+}
+
+// TailHandler returns an http.Handler that streams the entries log writes
+// from then on as newline-delimited JSON, until the client disconnects or
+// log is shut down. log must be the logger returned by New with WithTail;
+// for any other logger the handler responds 404. Clients that read too slowly
+// miss entries rather than slow the logger down.
+func TailHandler(log *zap.SugaredLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := tails.Load(log)
+		if !ok {
+			http.Error(w, "logger is not tailable", http.StatusNotFound)
+			return
+		}
+		hub := v.(*tailHub)
+
+		rc := http.NewResponseController(w)
+		ch := hub.subscribe()
+		defer hub.unsubscribe(ch)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case line := <-ch:
+				if _, err := w.Write(line); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			case <-hub.done:
+				return
+			}
+		}
+	})
+}
+
+// tailHub fans entries out to the connected TailHandler clients.
+type tailHub struct {
+	mu      sync.RWMutex
+	clients map[chan []byte]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (h *tailHub) subscribe() chan []byte {
+	ch := make(chan []byte, tailQueueSize)
+	h.mu.Lock()
+	h.clients[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *tailHub) unsubscribe(ch chan []byte) {
+	h.mu.Lock()
+	delete(h.clients, ch)
+	h.mu.Unlock()
+}
+
+// idle reports whether no client is connected.
+func (h *tailHub) idle() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients) == 0
+}
+
+// write sends a copy of line, which zap reuses, to every client.
+func (h *tailHub) write(_ zapcore.Entry, line []byte) error {
+	h.send(append([]byte(nil), line...))
+	return nil
+}
+
+// send queues line for every client with room for it.
+func (h *tailHub) send(line []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.clients {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// close disconnects every client.
+func (h *tailHub) close() error {
+	h.closeOnce.Do(func() { close(h.done) })
+	return nil
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTailHandler(t *testing.T) {
+	log, _ := newBufferLogger(t, WithoutSampling(), WithTail())
+	srv := httptest.NewServer(TailHandler(log))
+	defer srv.Close()
+
+	// The headers arrive once the handler has subscribed.
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+
+	// A client that never reads fills its queue without blocking the logger.
+	v, _ := tails.Load(log)
+	hub := v.(*tailHub)
+	stalled := hub.subscribe()
+	defer hub.unsubscribe(stalled)
+
+	log.Info("before")
+	log.Infow("streamed", "k", "v")
+	for i := 0; i < tailQueueSize; i++ {
+		log.Debug("filtered")
+		log.Infow("flood", "i", i)
+	}
+	if n := len(stalled); n != tailQueueSize {
+		t.Errorf("stalled client has %d entries queued, want %d", n, tailQueueSize)
+	}
+
+	lines := make(chan map[string]any)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+				t.Errorf("line %q isn't JSON: %v", sc.Text(), err)
+				return
+			}
+			lines <- line
+		}
+	}()
+	for _, want := range []string{"before", "streamed"} {
+		select {
+		case line := <-lines:
+			if line["msg"] != want || line["service"] != TestService {
+				t.Errorf("line = %v, want %s from the logger", line, want)
+			}
+			if want == "streamed" && line["k"] != "v" {
+				t.Errorf("line = %v, want its fields", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %q line streamed", want)
+		}
+	}
+
+	// Shutdown ends the stream.
+	if err := Shutdown(log); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("stream still open after Shutdown")
+		}
+	}
+}
+
+func TestTailHandlerWithoutTail(t *testing.T) {
+	log, _ := newBufferLogger(t)
+	rec := httptest.NewRecorder()
+	TailHandler(log).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tail", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 for a logger without WithTail", rec.Code)
+	}
+}
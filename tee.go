@@ -0,0 +1,69 @@
+package logger
+
+import "go.uber.org/zap/zapcore"
+
+// teeCore is an output teed with the core built from the output paths, such
+// as WithSentry or WithTail, that hands each entry it's enabled for to a
+// function rather than a WriteSyncer.
+type teeCore struct {
+	zapcore.LevelEnabler
+	// enc, when set, encodes each entry, with the fields added by With, and
+	// the encoded line is passed to encoded. Otherwise the fields added by
+	// With are kept in context and passed to write ahead of the entry's own.
+	enc     zapcore.Encoder
+	encoded func(ent zapcore.Entry, line []byte) error
+	context []zapcore.Field
+	write   func(ent zapcore.Entry, fields []zapcore.Field) error
+	// idle, when set, reports whether entries can be skipped for now, such
+	// as while nobody is listening. sync, when set, is called by Sync.
+	idle func() bool
+	sync func() error
+}
+
+func (c *teeCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	if c.enc != nil {
+		clone.enc = c.enc.Clone()
+		for _, f := range fields {
+			f.AddTo(clone.enc)
+		}
+	} else {
+		clone.context = append(c.context[:len(c.context):len(c.context)], fields...)
+	}
+	return &clone
+}
+
+func (c *teeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *teeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// Wrapping cores may write to a tee without checking each member.
+	if !c.Enabled(ent.Level) || (c.idle != nil && c.idle()) {
+		return nil
+	}
+
+	if c.enc == nil {
+		all := make([]zapcore.Field, 0, len(c.context)+len(fields))
+		all = append(all, c.context...)
+		all = append(all, fields...)
+		return c.write(ent, all)
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	return c.encoded(ent, buf.Bytes())
+}
+
+func (c *teeCore) Sync() error {
+	if c.sync == nil {
+		return nil
+	}
+	return c.sync()
+}
@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestService is the service name stamped on loggers returned by `NewTest()`.
+const TestService = "test"
+
+// NewTest constructs a Sugared Logger that records entries in memory instead
+// of writing them, for use in tests. Every level is captured and, like
+// `New()`, each entry carries the "service" field. The returned ObservedLogs
+// can be queried with methods such as All, FilterMessage, and FilterField.
+func NewTest() (*zap.SugaredLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(TraceLevel)
+	log := zap.New(core,
+		zap.WithCaller(true),
+		zap.Fields(zap.String("service", TestService)),
+	)
+	return log.Sugar(), logs
+}
@@ -0,0 +1,26 @@
+package logger
+
+import "testing"
+
+func TestNewTest(t *testing.T) {
+	log, logs := NewTest()
+	log.Named("worker").With("job", 1).Debugw("started", "attempt", 2)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	fields := e.ContextMap()
+	if fields["service"] != TestService || fields["job"] != int64(1) || fields["attempt"] != int64(2) {
+		t.Errorf("fields = %v", fields)
+	}
+	if e.LoggerName != "worker" || !e.Caller.Defined {
+		t.Errorf("entry = %+v, want the logger name and caller", e.Entry)
+	}
+
+	Trace(log, "fine detail")
+	if logs.FilterMessage("fine detail").Len() != 1 {
+		t.Error("trace entries aren't captured")
+	}
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithFieldTransform passes every field, including the initial fields and
+// those added with With, through fn before it's encoded. fn returns the field
+// to write in its place, which may have a new key or value, or false to drop
+// it. For example, to rename "user" and drop "password":
+//
+//	WithFieldTransform(func(f zapcore.Field) (zapcore.Field, bool) {
+//		switch f.Key {
+//		case "user":
+//			f.Key = "user_id"
+//		case "password":
+//			return f, false
+//		}
+//		return f, true
+//	})
+func WithFieldTransform(fn func(field zapcore.Field) (zapcore.Field, bool)) loggerOpt {
+	return func(b *builder) error {
+		if fn == nil {
+			return fmt.Errorf("%w: field transform requires a func", ErrInvalidOption)
+		}
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			return &rewriteCore{Core: core, field: transformField(fn)}
+		})
+		return nil
+	}
+}
+
+// transformField adapts fn to a rewriteCore field func. fn's result can't be
+// compared with the field it was given, so every field it keeps counts as
+// replaced.
+func transformField(fn func(zapcore.Field) (zapcore.Field, bool)) func(zapcore.Field) (zapcore.Field, fieldEdit) {
+	return func(f zapcore.Field) (zapcore.Field, fieldEdit) {
+		f, ok := fn(f)
+		if !ok {
+			return f, fieldDropped
+		}
+		return f, fieldReplaced
+	}
+}
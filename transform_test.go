@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithFieldTransform(t *testing.T) {
+	log, buf := newBufferLogger(t, WithFieldTransform(func(f zapcore.Field) (zapcore.Field, bool) {
+		switch f.Key {
+		case "user":
+			f.Key = "user_id"
+		case "password":
+			return f, false
+		}
+		return f, true
+	}))
+	log.With("user", "bob", "password", "hunter2").Infow("login", "attempt", 2, "password", "hunter3")
+
+	line := decodeLines(t, buf)[0]
+	if line["user_id"] != "bob" || line["attempt"] != float64(2) || line["service"] != TestService {
+		t.Errorf("line = %v, want user renamed to user_id and the other fields kept", line)
+	}
+	for _, key := range []string{"user", "password"} {
+		if _, ok := line[key]; ok {
+			t.Errorf("line = %v, want no %q", line, key)
+		}
+	}
+
+	if _, err := New(TestService, WithFieldTransform(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("nil func error = %v, want ErrInvalidOption", err)
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const ellipsis = "…"
+
+// WithMaxMessageLength bounds the size of entries by cutting the message, and
+// any string field, down to n runes followed by an ellipsis. Entries whose
+// message or fields were cut carry a "truncated":true field.
+func WithMaxMessageLength(n int) loggerOpt {
+	return func(b *builder) error {
+		if n <= 0 {
+			return fmt.Errorf("%w: max message length %d must be positive", ErrInvalidOption, n)
+		}
+		b.cores = append(b.cores, func(core zapcore.Core) zapcore.Core {
+			t := truncater(n)
+			return &rewriteCore{Core: core, field: t.field, entry: t.entry}
+		})
+		return nil
+	}
+}
+
+// truncater cuts strings down to its length in runes.
+type truncater int
+
+// field cuts long string fields.
+func (t truncater) field(f zapcore.Field) (zapcore.Field, fieldEdit) {
+	if f.Type != zapcore.StringType {
+		return f, fieldKept
+	}
+	s, cut := truncate(f.String, int(t))
+	if !cut {
+		return f, fieldKept
+	}
+	f.String = s
+	return f, fieldReplaced
+}
+
+// entry cuts a long message, marking the entry if it or any field was cut.
+func (t truncater) entry(ent zapcore.Entry, fields []zapcore.Field, edited bool) (zapcore.Entry, []zapcore.Field) {
+	msg, cut := truncate(ent.Message, int(t))
+	if cut || edited {
+		ent.Message = msg
+		fields = appendField(fields, zap.Bool("truncated", true))
+	}
+	return ent, fields
+}
+
+// truncate cuts s to at most n runes, appending an ellipsis if it was cut.
+func truncate(s string, n int) (string, bool) {
+	if len(s) <= n || utf8.RuneCountInString(s) <= n {
+		return s, false
+	}
+	i := 0
+	for ; n > 0; n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i] + ellipsis, true
+}
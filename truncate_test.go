@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxMessageLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       string
+		field     string
+		wantMsg   string
+		wantField string
+		truncated bool
+	}{
+		{"short", "hello", "ok", "hello", "ok", false},
+		{"exact", "héllo", "ok", "héllo", "ok", false},
+		{"long message", "hello world", "ok", "hello…", "ok", true},
+		{"multibyte", "日本語のテキスト", "ok", "日本語のテ…", "ok", true},
+		{"long field", "hi", strings.Repeat("x", 10), "hi", "xxxxx…", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger(t, WithMaxMessageLength(5))
+			log.Infow(tt.msg, "detail", tt.field)
+
+			line := decodeLines(t, buf)[0]
+			if line["msg"] != tt.wantMsg || line["detail"] != tt.wantField {
+				t.Errorf("line = %v, want msg %q and detail %q", line, tt.wantMsg, tt.wantField)
+			}
+			if _, ok := line["truncated"]; ok != tt.truncated {
+				t.Errorf("truncated present = %v, want %v", ok, tt.truncated)
+			}
+		})
+	}
+
+	if _, err := New(TestService, WithMaxMessageLength(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("zero length error = %v, want ErrInvalidOption", err)
+	}
+}